@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"consistent_1/Domain"
+	"consistent_1/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementController struct {
+	achievementUsecase usecases.AchievementUsecase
+}
+
+func NewAchievementController(achievementUsecase usecases.AchievementUsecase) *AchievementController {
+	return &AchievementController{
+		achievementUsecase: achievementUsecase,
+	}
+}
+
+// GetAchievements lists every achievement with the user's unlock state and progress.
+func (ctrl *AchievementController) GetAchievements(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	achievements, err := ctrl.achievementUsecase.GetAchievements(c.Request.Context(), userID, c.GetString("locale"))
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": message(c, "error.user_not_found")})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error getting achievements", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_achievements_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, achievements)
+}
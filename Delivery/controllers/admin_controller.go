@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"consistent_1/Domain"
+	"consistent_1/Usecases"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminController struct {
+	platformUsecase usecases.PlatformUsecase
+	jobUsecase      usecases.JobUsecase
+}
+
+func NewAdminController(platformUsecase usecases.PlatformUsecase, jobUsecase usecases.JobUsecase) *AdminController {
+	return &AdminController{
+		platformUsecase: platformUsecase,
+		jobUsecase:      jobUsecase,
+	}
+}
+
+// GetPlatformStatuses reports each platform's rate limit and circuit breaker state.
+func (ctrl *AdminController) GetPlatformStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.platformUsecase.GetPlatformStatuses())
+}
+
+// ListJobs lists queued jobs, most recently updated first. Filter with
+// ?type= and ?status=, e.g. status=dead for dead-lettered jobs.
+func (ctrl *AdminController) ListJobs(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+	jobs, err := ctrl.jobUsecase.ListJobs(c.Request.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error listing jobs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.list_jobs_failed")})
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob returns a job with every recorded run, including their errors.
+func (ctrl *AdminController) GetJob(c *gin.Context) {
+	job, err := ctrl.jobUsecase.GetJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == domain.ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error getting job", "job_id", c.Param("id"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.list_jobs_failed")})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// RequeueJob runs a failed or dead job again now with a fresh set of attempts.
+func (ctrl *AdminController) RequeueJob(c *gin.Context) {
+	job, err := ctrl.jobUsecase.Requeue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrJobNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
+		case domain.ErrJobNotRequeueable:
+			c.JSON(http.StatusConflict, gin.H{"error": errorMessage(c, err)})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error re-enqueueing job", "job_id", c.Param("id"), "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.requeue_job_failed")})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ListJobRuns lists run history, newest first. Filter with ?type=, ?status=
+// (e.g. failed) and ?jobId=.
+func (ctrl *AdminController) ListJobRuns(c *gin.Context) {
+	filter, ok := jobFilter(c)
+	if !ok {
+		return
+	}
+	if jobID := c.Query("jobId"); jobID != "" {
+		objID, err := primitive.ObjectIDFromHex(jobID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_job_id")})
+			return
+		}
+		filter.JobID = objID
+	}
+	runs, err := ctrl.jobUsecase.ListRuns(c.Request.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error listing job runs", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.list_jobs_failed")})
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// jobFilter reads the filters shared by the job listings, writing a 400
+// response and returning ok=false if pagination is malformed.
+func jobFilter(c *gin.Context) (domain.JobFilter, bool) {
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return domain.JobFilter{}, false
+	}
+	return domain.JobFilter{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+		Limit:  limit,
+		Offset: offset,
+	}, true
+}
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -33,7 +33,7 @@ func (ctrl *ConsistencyController) GetDailyConsistency(c *gin.Context) {
 		var err error
 		queryDate, err = time.Parse("2006-01-02", dateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_date")})
 			return
 		}
 	}
@@ -42,10 +42,10 @@ func (ctrl *ConsistencyController) GetDailyConsistency(c *gin.Context) {
 	if err != nil {
 		switch err {
 		case domain.ErrConsistencyNotFound:
-			c.JSON(http.StatusNotFound, gin.H{"error": "No consistency record found for this date"})
+			c.JSON(http.StatusNotFound, gin.H{"error": message(c, "error.consistency_not_found_for_date")})
 		default:
-			log.Printf("Error getting daily consistency for user %s, date %s: %v", userID, queryDate.Format("2006-01-02"), err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve daily consistency"})
+			slog.ErrorContext(c.Request.Context(), "Error getting daily consistency", "date", queryDate.Format("2006-01-02"), "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_daily_consistency_failed")})
 		}
 		return
 	}
@@ -65,7 +65,7 @@ func (ctrl *ConsistencyController) GetConsistencyHistory(c *gin.Context) {
 	if startDateStr != "" {
 		t, e := time.Parse("2006-01-02", startDateStr)
 		if e != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid startDate format. Expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_start_date")})
 			return
 		}
 		startDate = &t
@@ -74,7 +74,7 @@ func (ctrl *ConsistencyController) GetConsistencyHistory(c *gin.Context) {
 	if endDateStr != "" {
 		t, e := time.Parse("2006-01-02", endDateStr)
 		if e != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid endDate format. Expected YYYY-MM-DD"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_end_date")})
 			return
 		}
 		endDate = &t
@@ -82,8 +82,8 @@ func (ctrl *ConsistencyController) GetConsistencyHistory(c *gin.Context) {
 
 	history, err := ctrl.consistencyUsecase.GetConsistencyHistory(c.Request.Context(), userID, startDate, endDate)
 	if err != nil {
-		log.Printf("Error getting consistency history for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve consistency history"})
+		slog.ErrorContext(c.Request.Context(), "Error getting consistency history", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_consistency_history_failed")})
 		return
 	}
 
@@ -96,8 +96,8 @@ func (ctrl *ConsistencyController) GetUserStreaks(c *gin.Context) {
 
 	streakInfo, err := ctrl.consistencyUsecase.GetStreaks(c.Request.Context(), userID)
 	if err != nil {
-		log.Printf("Error getting streaks for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve streak information"})
+		slog.ErrorContext(c.Request.Context(), "Error getting streaks", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_streaks_failed")})
 		return
 	}
 
@@ -110,10 +110,10 @@ func (ctrl *ConsistencyController) TriggerDailyConsistencyCheck(c *gin.Context)
 
 	consistency, err := ctrl.consistencyUsecase.CheckDailyConsistency(c.Request.Context(), userID)
 	if err != nil {
-		log.Printf("Error manually triggering consistency check for user %s: %v", userID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to trigger consistency check"})
+		slog.ErrorContext(c.Request.Context(), "Error manually triggering consistency check", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.trigger_consistency_check_failed")})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Daily consistency check triggered successfully", "consistency": consistency})
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.consistency_check_triggered"), "consistency": consistency})
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"consistent_1/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	healthUsecase usecases.HealthUsecase
+}
+
+func NewHealthController(healthUsecase usecases.HealthUsecase) *HealthController {
+	return &HealthController{
+		healthUsecase: healthUsecase,
+	}
+}
+
+// Healthz answers as long as the process is serving requests.
+func (ctrl *HealthController) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz probes every dependency and answers 503 if any is down, so load
+// balancers stop routing to this instance.
+func (ctrl *HealthController) Readyz(c *gin.Context) {
+	readiness := ctrl.healthUsecase.Ready(c.Request.Context())
+	if !readiness.Ready {
+		c.JSON(http.StatusServiceUnavailable, readiness)
+		return
+	}
+	c.JSON(http.StatusOK, readiness)
+}
+
+// Status summarizes readiness, the last nightly run, platform API error rates
+// and queue depth for operators.
+func (ctrl *HealthController) Status(c *gin.Context) {
+	status, err := ctrl.healthUsecase.Status(c.Request.Context())
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error building status", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.status_failed")})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"consistent_1/Infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// message renders a catalog message in the locale negotiated by LocaleMiddleware.
+func message(c *gin.Context, key string) string {
+	return i18n.T(c.GetString("locale"), key, nil)
+}
+
+// errorMessage renders err in the locale negotiated by LocaleMiddleware.
+func errorMessage(c *gin.Context, err error) string {
+	return i18n.Error(c.GetString("locale"), err)
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"consistent_1/Domain"
+	"consistent_1/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type NotificationController struct {
+	notificationUsecase usecases.NotificationUsecase
+}
+
+func NewNotificationController(notificationUsecase usecases.NotificationUsecase) *NotificationController {
+	return &NotificationController{
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (ctrl *NotificationController) GetNotificationHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	history, err := ctrl.notificationUsecase.GetUserNotifications(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting notification history", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_notification_history_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetInbox serves GET /inbox, newest first, with the user's unread count.
+func (ctrl *NotificationController) GetInbox(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	page, err := ctrl.notificationUsecase.GetInbox(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error getting inbox", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_inbox_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (ctrl *NotificationController) MarkInboxItemRead(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	err := ctrl.notificationUsecase.MarkInboxItemRead(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		if err == domain.ErrInboxItemNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": message(c, "error.inbox_item_not_found")})
+			return
+		}
+		slog.ErrorContext(c.Request.Context(), "Error marking inbox item read", "inbox_item_id", c.Param("id"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.mark_inbox_item_read_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.inbox_item_read")})
+}
+
+func (ctrl *NotificationController) MarkAllInboxItemsRead(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	updated, err := ctrl.notificationUsecase.MarkAllInboxItemsRead(c.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error marking inbox read", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.mark_inbox_read_failed")})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.inbox_read"), "updated": updated})
+}
+
+// parsePagination reads the 'limit' and 'offset' query params, writing a 400
+// response and returning ok=false if either is malformed.
+func parsePagination(c *gin.Context) (limit, offset int64, ok bool) {
+	limit = defaultPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		l, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || l < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_limit")})
+			return 0, 0, false
+		}
+		limit = l
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		o, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || o < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_offset")})
+			return 0, 0, false
+		}
+		offset = o
+	}
+	return limit, offset, true
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"consistent_1/Domain"
+	"consistent_1/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	reportUsecase usecases.ReportUsecase
+}
+
+func NewReportController(reportUsecase usecases.ReportUsecase) *ReportController {
+	return &ReportController{
+		reportUsecase: reportUsecase,
+	}
+}
+
+// GetWeeklyReport serves GET /reports/weekly?week=2026-W42; without 'week' it
+// reports on the user's current ISO week.
+func (ctrl *ReportController) GetWeeklyReport(c *gin.Context) {
+	ctrl.getReport(c, domain.ReportPeriodWeekly, c.Query("week"))
+}
+
+// GetMonthlyReport serves GET /reports/monthly?month=2026-10; without 'month'
+// it reports on the user's current month.
+func (ctrl *ReportController) GetMonthlyReport(c *gin.Context) {
+	ctrl.getReport(c, domain.ReportPeriodMonthly, c.Query("month"))
+}
+
+func (ctrl *ReportController) getReport(c *gin.Context, period, label string) {
+	userID := c.MustGet("userID").(string)
+
+	report, err := ctrl.reportUsecase.GetReport(c.Request.Context(), userID, period, label)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidReportPeriod:
+			c.JSON(http.StatusBadRequest, gin.H{"error": message(c, "error.invalid_report_period")})
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": message(c, "error.user_not_found")})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error building report", "period", period, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.build_report_failed")})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
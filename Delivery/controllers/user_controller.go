@@ -1,7 +1,8 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	domain "consistent_1/Domain"
@@ -52,37 +53,39 @@ func NewUserController(userUsecase usecases.UserUsecase) *UserController {
 // }
 
 func (ctrl *UserController) RegisterUser(c *gin.Context) {
-	log.Printf("--- RegisterUser: Received request ---") // Log entry point
 
 	var req domain.UserRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("RegisterUser: JSON binding error: %v", err) // Log binding error
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.InfoContext(c.Request.Context(), "RegisterUser: JSON binding error", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
 		return
 	}
+	if req.Locale == "" {
+		req.Locale = c.GetString("locale")
+	}
 	// Log success and request data. Be careful not to log sensitive info like raw passwords in production.
-	log.Printf("RegisterUser: JSON bound successfully. Email: %s, Username: %s", req.Email, req.Username)
+	slog.DebugContext(c.Request.Context(), "RegisterUser: JSON bound successfully", "username", req.Username)
 
 	user, err := ctrl.userUsecase.RegisterUser(c.Request.Context(), &req)
 	if err != nil {
-		log.Printf("RegisterUser: Error from usecase: %v", err) // Log usecase error
+		slog.InfoContext(c.Request.Context(), "RegisterUser: Error from usecase", "error", err)
 		switch err {
 		case domain.ErrPasswordsDoNotMatch:
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
 		case domain.ErrEmailAlreadyExists:
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-		case domain.ErrInvalidNotificationTime:
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(http.StatusConflict, gin.H{"error": errorMessage(c, err)})
+		case domain.ErrInvalidNotificationTime, domain.ErrUnsupportedLocale:
+			c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
 		default:
-			log.Printf("RegisterUser: Unhandled error during registration: %v", err) // More specific message for default
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+			slog.ErrorContext(c.Request.Context(), "RegisterUser: Unhandled error during registration", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.register_failed")})
 		}
 		return
 	}
 
-	log.Printf("RegisterUser: User registered successfully: %s", user.Email) // Log success
+	slog.InfoContext(c.Request.Context(), "RegisterUser: User registered successfully", "user_id", user.ID.Hex())
 	c.JSON(http.StatusCreated, gin.H{
-		"message":  "User registered successfully",
+		"message":  message(c, "success.registered"),
 		"userID":   user.ID.Hex(),
 		"username": user.Username,
 		"email":    user.Email,
@@ -92,7 +95,7 @@ func (ctrl *UserController) RegisterUser(c *gin.Context) {
 func (ctrl *UserController) LoginUser(c *gin.Context) {
 	var req domain.UserLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
 		return
 	}
 
@@ -100,15 +103,15 @@ func (ctrl *UserController) LoginUser(c *gin.Context) {
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidCredentials:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errorMessage(c, err)})
 		default:
-			log.Printf("Error logging in user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+			slog.ErrorContext(c.Request.Context(), "Error logging in user", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.login_failed")})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "token": token})
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.logged_in"), "token": token})
 }
 
 func (ctrl *UserController) GetUserProfile(c *gin.Context) {
@@ -118,10 +121,10 @@ func (ctrl *UserController) GetUserProfile(c *gin.Context) {
 	if err != nil {
 		switch err {
 		case domain.ErrUserNotFound:
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
 		default:
-			log.Printf("Error getting user profile for %s: %v", userID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve profile"})
+			slog.ErrorContext(c.Request.Context(), "Error getting user profile", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_profile_failed")})
 		}
 		return
 	}
@@ -135,23 +138,108 @@ func (ctrl *UserController) UpdateUserProfile(c *gin.Context) {
 
 	var req domain.UserProfileUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
 		return
 	}
 
 	err := ctrl.userUsecase.UpdateUserProfile(c.Request.Context(), userID, &req)
+	var handleErr *domain.PlatformHandleError
+	if errors.As(err, &handleErr) {
+		respondPlatformHandleError(c, handleErr)
+		return
+	}
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
+		case domain.ErrInvalidNotificationTime, domain.ErrUnsupportedChannel, domain.ErrInvalidWebhookURL, domain.ErrUnsupportedLocale:
+			c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error updating user profile", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.update_profile_failed")})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.profile_updated")})
+}
+
+func (ctrl *UserController) RemoveDevice(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+	token := c.Param("token")
+
+	err := ctrl.userUsecase.RemoveDevice(c.Request.Context(), userID, token)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound, domain.ErrDeviceNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error removing device", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.remove_device_failed")})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.device_removed")})
+}
+
+func (ctrl *UserController) GetNotificationSettings(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	settings, err := ctrl.userUsecase.GetNotificationSettings(c.Request.Context(), userID)
 	if err != nil {
 		switch err {
 		case domain.ErrUserNotFound:
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		case domain.ErrInvalidNotificationTime:
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
 		default:
-			log.Printf("Error updating user profile for %s: %v", userID, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			slog.ErrorContext(c.Request.Context(), "Error getting notification settings", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.get_notification_settings_failed")})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully"})
+	c.JSON(http.StatusOK, settings)
+}
+
+func (ctrl *UserController) UpdateNotificationSettings(c *gin.Context) {
+	userID := c.MustGet("userID").(string)
+
+	var req domain.NotificationSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
+		return
+	}
+
+	err := ctrl.userUsecase.UpdateNotificationSettings(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch err {
+		case domain.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(c, err)})
+		case domain.ErrUnsupportedChannel, domain.ErrUnsupportedNotificationKind, domain.ErrInvalidQuietHours:
+			c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(c, err)})
+		default:
+			slog.ErrorContext(c.Request.Context(), "Error updating notification settings", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": message(c, "error.update_notification_settings_failed")})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message(c, "success.notification_settings_updated")})
+}
+
+// respondPlatformHandleError names the rejected handle so clients can point
+// the user at the offending field.
+func respondPlatformHandleError(c *gin.Context, handleErr *domain.PlatformHandleError) {
+	status := http.StatusBadRequest
+	text := errorMessage(c, handleErr)
+	if errors.Is(handleErr, domain.ErrExternalAPIFailed) {
+		slog.InfoContext(c.Request.Context(), "Platform handle validation failed", "platform", handleErr.Platform, "handle", handleErr.Username, "error", handleErr.Err)
+		status = http.StatusServiceUnavailable
+		text = message(c, "error.platform_verification_unavailable")
+	}
+	c.JSON(status, gin.H{
+		"error":    text,
+		"platform": handleErr.Platform,
+		"username": handleErr.Username,
+	})
 }
@@ -101,118 +101,370 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os" // Ensure "os" is imported
 	"os/signal"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"consistent_1/Delivery/controllers"
 	"consistent_1/Delivery/routers"
+	"consistent_1/Domain"
 	"consistent_1/Infrastructure/auth"
+	"consistent_1/Infrastructure/config"
 	"consistent_1/Infrastructure/database"
+	"consistent_1/Infrastructure/logging"
+	"consistent_1/Infrastructure/migrations"
 	"consistent_1/Infrastructure/notifications"
 	"consistent_1/Infrastructure/platform_api"
 	"consistent_1/Infrastructure/scheduler"
+	"consistent_1/Infrastructure/tracing"
 	"consistent_1/Repositories"
 	"consistent_1/Usecases"
 
-	firebase "firebase.google.com/go"
-	"github.com/spf13/viper"
+	firebase "firebase.google.com/go/v4"
+	"github.com/spf13/pflag"
 	"google.golang.org/api/option"
 )
 
 func main() {
-	viper.SetConfigFile(".env")
-	viper.AutomaticEnv()
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+	logging.Setup()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, pflag.ErrHelp) {
+		return
 	}
-
-	serverPort := viper.GetString("SERVER_PORT")
-	if serverPort == "" {
-		serverPort = ":8080"
-	}
-	jwtSecret := viper.GetString("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET not set in environment variables")
+	if err != nil {
+		logging.Fatal("Invalid configuration", "error", err)
 	}
+	logging.SetLevel(cfg.LogLevel)
 
-	// --- START MODIFIED FIREBASE INITIALIZATION ---
-
-	// Read the service account file path from .env (which Dockerfile creates and points to the dynamic file)
-	firebaseServiceAccountPath := viper.GetString("FIREBASE_SERVICE_ACCOUNT_PATH")
-	if firebaseServiceAccountPath == "" {
-		log.Fatal("FIREBASE_SERVICE_ACCOUNT_PATH not set in environment variables. Push notifications will not work.")
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logging.Fatal("Error setting up tracing", "error", err)
 	}
 
-	// Read the project ID from a Render environment variable (set on Render's UI)
-	firebaseProjectID := os.Getenv("FIREBASE_PROJECT_ID") // Using os.Getenv to directly read Render env var
-	if firebaseProjectID == "" {
-		log.Fatal("FIREBASE_PROJECT_ID environment variable not set on Render. Push notifications will not work.")
+	var mongoClient *database.MongoClient
+	if cfg.Dev() {
+		slog.Warn("Running in dev mode: data is kept in memory and lost on exit.")
+	} else {
+		mongoClient, err = database.NewMongoClient(cfg.Mongo.URI, cfg.Mongo.Database, cfg.Mongo.ConnectTimeout)
+		if err != nil {
+			logging.Fatal("Failed to connect to MongoDB", "error", err)
+		}
+		defer func() {
+			if err := mongoClient.Disconnect(context.Background()); err != nil {
+				slog.Error("Error disconnecting from MongoDB", "error", err)
+			}
+		}()
 	}
-
-	// Create a Firebase config with the explicit project ID. This is the fix.
-	config := &firebase.Config{
-		ProjectID: firebaseProjectID,
+	repos := newRepositories(mongoClient)
+	if cfg.Command != "" {
+		runCommand(cfg.Command, migrations.NewMigrator(mongoClient.DB, repos.leases))
+		return
+	}
+	if mongoClient != nil {
+		migrateOnStartup(cfg.Mongo, migrations.NewMigrator(mongoClient.DB, repos.leases))
 	}
 
-	// Initialize Firebase app using the explicit config and the credentials file.
-	// The 'nil' from your original code is replaced by 'config'.
-	firebaseApp, err := firebase.NewApp(context.Background(), config, option.WithCredentialsFile(firebaseServiceAccountPath))
+	passwordService := auth.NewPasswordService()
+	jwtService := auth.NewJWTService(cfg.JWTSecret)
+	userRepo := repos.users
+	fcmService, err := newFCMService(cfg.Firebase, userRepo)
 	if err != nil {
-		log.Fatalf("Error initializing Firebase app: %v", err)
+		logging.Fatal("Error initializing Firebase", "error", err)
 	}
-	log.Println("Firebase Admin SDK initialized successfully.")
-
-	// --- END MODIFIED FIREBASE INITIALIZATION ---
-
-
-	mongoClient, err := database.NewMongoClient()
+	leetcodeAPI, codeforcesAPI, err := platformAPIs(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		logging.Fatal("Error loading platform fixtures", "error", err)
+	}
+	consistencyRepo := repos.consistency
+	notificationRepo := repos.notifications
+	inboxRepo := repos.inbox
+	achievementRepo := repos.achievements
+	notifiers := buildNotifiers(cfg.Notifications, fcmService)
+	if cfg.Dev() {
+		notifiers = logNotifiers()
 	}
-	defer func() {
-		if err := mongoClient.Disconnect(context.Background()); err != nil {
-			log.Printf("Error disconnecting from MongoDB: %v", err)
-		}
-	}()
-
-	passwordService := auth.NewPasswordService()
-	jwtService := auth.NewJWTService(jwtSecret)
-	fcmService := notifications.NewFCMService(firebaseApp)
-	leetcodeAPI := platform_api.NewLeetCodeAPI(viper.GetString("LEETCODE_API_BASE_URL"))
-	codeforcesAPI := platform_api.NewCodeforcesAPI(viper.GetString("CODEFORCES_API_BASE_URL"))
-	userRepo := repositories.NewUserRepository(mongoClient.DB)
-	consistencyRepo := repositories.NewConsistencyRepository(mongoClient.DB)
 	platformUsecase := usecases.NewPlatformUsecase(userRepo, leetcodeAPI, codeforcesAPI)
-	userUsecase := usecases.NewUserUsecase(userRepo, passwordService, jwtService)
-	consistencyUsecase := usecases.NewConsistencyUsecase(userRepo, consistencyRepo, platformUsecase, fcmService)
+	userUsecase := usecases.NewUserUsecase(userRepo, passwordService, jwtService, platformUsecase)
+	notificationUsecase := usecases.NewNotificationUsecase(userRepo, notificationRepo, inboxRepo, notifiers)
+	achievementUsecase := usecases.NewAchievementUsecase(consistencyRepo, achievementRepo, notificationUsecase)
+	consistencyUsecase := usecases.NewConsistencyUsecase(userRepo, consistencyRepo, platformUsecase, notificationUsecase, achievementUsecase,
+		usecases.EscalationPolicy{Offsets: cfg.Consistency.EscalationOffsets},
+		usecases.CheckPolicy{Concurrency: cfg.Consistency.CheckConcurrency, UserTimeout: cfg.Consistency.CheckUserTimeout})
+	reportUsecase := usecases.NewReportUsecase(userRepo, consistencyUsecase, notificationUsecase, cfg.Consistency.DigestTime)
+	jobRepo := repos.jobs
+	jobUsecase := usecases.NewJobUsecase(jobRepo, usecases.NewConsistencyJobHandlers(consistencyUsecase))
 	userController := controllers.NewUserController(userUsecase)
 	consistencyController := controllers.NewConsistencyController(consistencyUsecase)
-	router := routers.SetupRouter(userController, consistencyController, jwtService)
-	consistencyScheduler := scheduler.NewConsistencyScheduler(consistencyUsecase, userUsecase)
-	consistencyScheduler.ScheduleDailyConsistencyCheck()
-	consistencyScheduler.ScheduleNotificationReminders()
-	consistencyScheduler.Start()
-	defer consistencyScheduler.Stop()
-	go func() {
-		log.Printf("Server starting on %s", serverPort)
-		if err := router.Run(serverPort); err != nil {
-			log.Fatalf("Server failed to start: %v", err)
+	notificationController := controllers.NewNotificationController(notificationUsecase)
+	reportController := controllers.NewReportController(reportUsecase)
+	achievementController := controllers.NewAchievementController(achievementUsecase)
+	adminController := controllers.NewAdminController(platformUsecase, jobUsecase)
+	leaseRepo := repos.leases
+	var elector *scheduler.LeaderElector
+	if cfg.Server.RunWorker {
+		elector = scheduler.NewLeaderElector(leaseRepo, domain.SchedulerLeaseName, cfg.Scheduler.LeaseTTL)
+	}
+	healthUsecase := usecases.NewHealthUsecase(readinessChecks(mongoClient, fcmService, elector), platformUsecase, jobRepo, notificationRepo, leaseRepo)
+	healthController := controllers.NewHealthController(healthUsecase)
+	router := routers.SetupRouter(userController, consistencyController, notificationController, reportController, achievementController, adminController, healthController, jwtService, cfg.AdminAPIToken, cfg.Server.CORSOrigins)
+	// rootCtx bounds all scheduled work; it is cancelled once shutdown has given
+	// running jobs their chance to finish.
+	rootCtx, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+	var consistencyScheduler *scheduler.ConsistencyScheduler
+	if cfg.Server.RunWorker {
+		consistencyScheduler = scheduler.NewConsistencyScheduler(rootCtx, consistencyUsecase, userUsecase, notificationUsecase, reportUsecase, jobUsecase, elector)
+		specs := cfg.Scheduler
+		for _, schedule := range []func() error{
+			func() error { return consistencyScheduler.ScheduleDailyConsistencyCheck(specs.DailyCheckSpec) },
+			func() error { return consistencyScheduler.ScheduleNotificationReminders(specs.RemindersSpec) },
+			func() error { return consistencyScheduler.ScheduleStreakEscalations(specs.EscalationsSpec) },
+			func() error { return consistencyScheduler.ScheduleSyncRetries(specs.SyncRetriesSpec) },
+			func() error { return consistencyScheduler.ScheduleDigests(specs.DigestsSpec) },
+			func() error { return consistencyScheduler.ScheduleOutboxWorker(specs.OutboxSpec) },
+			func() error { return consistencyScheduler.ScheduleJobWorkers(specs.JobWorkers, specs.JobPollSpec) },
+		} {
+			if err := schedule(); err != nil {
+				logging.Fatal("Error scheduling jobs", "error", err)
+			}
 		}
-	}()
+		consistencyScheduler.Start()
+	}
+	var server *http.Server
+	if cfg.Server.RunAPI {
+		server = &http.Server{Addr: cfg.Server.Addr, Handler: router, ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout}
+		go func() {
+			slog.Info("Server starting", "addr", cfg.Server.Addr)
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logging.Fatal("Server failed to start", "error", err)
+			}
+		}()
+	}
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
-	_, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	// Stop taking requests and let in-flight ones finish, then give running
+	// jobs whatever is left of the shutdown timeout before cancelling them.
+	// The default stays inside Render's 30-second termination grace period.
+	slog.Info("Shutting down server...", "timeout", cfg.Server.ShutdownTimeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
-	log.Println("Server gracefully stopped.")
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("Error shutting down HTTP server", "error", err)
+		}
+	}
+	if consistencyScheduler != nil {
+		consistencyScheduler.Stop(ctx)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
+	}
+	slog.Info("Server gracefully stopped.")
 }
 
+// runCommand runs a command named on the command line instead of the service.
+func runCommand(command string, migrator *migrations.Migrator) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	switch command {
+	case config.CommandMigrate:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			logging.Fatal("Schema migration failed", "applied", applied, "error", err)
+		}
+		slog.Info("Schema is up to date.", "applied", applied)
+	case config.CommandMigrateStatus:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logging.Fatal("Error reading schema migrations", "error", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+		w.Flush()
+	}
+}
 
+// migrateOnStartup applies pending schema migrations or, if that is turned
+// off, refuses to start until they have been applied.
+func migrateOnStartup(cfg config.MongoConfig, migrator *migrations.Migrator) {
+	ctx := context.Background()
+	if cfg.MigrateOnStartup {
+		if _, err := migrator.Up(ctx); err != nil {
+			logging.Fatal("Schema migration failed", "error", err)
+		}
+		return
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		logging.Fatal("Error reading schema migrations", "error", err)
+	}
+	if len(pending) > 0 {
+		logging.Fatal("Schema migrations are pending; apply them with the migrate command", "pending", len(pending), "next", pending[0].Version)
+	}
+}
 
+// newFCMService connects to Firebase Cloud Messaging, or returns nil when
+// Firebase is not configured.
+func newFCMService(cfg config.FirebaseConfig, tokenStore notifications.DeviceTokenStore) (notifications.FCMService, error) {
+	if !cfg.Enabled() {
+		slog.Info("Firebase not configured; push notifications disabled.")
+		return nil, nil
+	}
+	opts := []option.ClientOption{option.WithCredentialsFile(cfg.ServiceAccountPath)}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+	app, err := firebase.NewApp(context.Background(), &firebase.Config{ProjectID: cfg.ProjectID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Firebase Admin SDK initialized successfully.")
+	return notifications.NewFCMService(app, tokenStore)
+}
+
+// repositorySet holds the stores shared by every component.
+type repositorySet struct {
+	users         repositories.UserRepository
+	consistency   repositories.ConsistencyRepository
+	notifications repositories.NotificationRepository
+	inbox         repositories.InboxRepository
+	achievements  repositories.AchievementRepository
+	jobs          repositories.JobRepository
+	leases        repositories.LeaseRepository
+}
+
+// newRepositories stores data in mongoClient's database, or in memory when
+// mongoClient is nil, as in dev mode.
+func newRepositories(mongoClient *database.MongoClient) repositorySet {
+	if mongoClient == nil {
+		return repositorySet{
+			users:         repositories.NewMemoryUserRepository(),
+			consistency:   repositories.NewMemoryConsistencyRepository(),
+			notifications: repositories.NewMemoryNotificationRepository(),
+			inbox:         repositories.NewMemoryInboxRepository(),
+			achievements:  repositories.NewMemoryAchievementRepository(),
+			jobs:          repositories.NewMemoryJobRepository(),
+			leases:        repositories.NewMemoryLeaseRepository(),
+		}
+	}
+	return repositorySet{
+		users:         repositories.NewUserRepository(mongoClient.DB),
+		consistency:   repositories.NewConsistencyRepository(mongoClient.DB),
+		notifications: repositories.NewNotificationRepository(mongoClient.DB),
+		inbox:         repositories.NewInboxRepository(mongoClient.DB),
+		achievements:  repositories.NewAchievementRepository(mongoClient.DB),
+		jobs:          repositories.NewJobRepository(mongoClient.DB),
+		leases:        repositories.NewLeaseRepository(mongoClient.DB),
+	}
+}
+
+// platformAPIs builds a guarded client for each enabled platform, or in dev
+// mode a client serving the fixtures file, and a stand-in that rejects every
+// call for each disabled one.
+func platformAPIs(cfg *config.Config) (platform_api.LeetCodeAPI, platform_api.CodeforcesAPI, error) {
+	var fixtures platform_api.PlatformFixtures
+	if cfg.Dev() {
+		var err error
+		if fixtures, err = platform_api.LoadPlatformFixtures(cfg.PlatformFixtures); err != nil {
+			return nil, nil, err
+		}
+		slog.Info("Serving platform activity from fixtures.", "file", cfg.PlatformFixtures)
+	}
+
+	var leetcodeAPI platform_api.LeetCodeAPI = platform_api.NewDisabledAPI("leetcode")
+	switch {
+	case !cfg.LeetCode.Enabled:
+		slog.Info("LeetCode disabled.")
+	case cfg.Dev():
+		leetcodeAPI = platform_api.NewFixtureAPI("leetcode", fixtures)
+	default:
+		leetcodeAPI = platform_api.NewLeetCodeAPI(cfg.LeetCode.BaseURL, platform_api.NewGuard("leetcode", cfg.LeetCode.Guard))
+	}
+	var codeforcesAPI platform_api.CodeforcesAPI = platform_api.NewDisabledAPI("codeforces")
+	switch {
+	case !cfg.Codeforces.Enabled:
+		slog.Info("Codeforces disabled.")
+	case cfg.Dev():
+		codeforcesAPI = platform_api.NewFixtureAPI("codeforces", fixtures)
+	default:
+		codeforcesAPI = platform_api.NewCodeforcesAPI(cfg.Codeforces.BaseURL, platform_api.NewGuard("codeforces", cfg.Codeforces.Guard))
+	}
+	return leetcodeAPI, codeforcesAPI, nil
+}
 
+func readinessChecks(mongoClient *database.MongoClient, fcmService notifications.FCMService, elector *scheduler.LeaderElector) []usecases.DependencyCheck {
+	var checks []usecases.DependencyCheck
+	if mongoClient != nil {
+		checks = append(checks, usecases.DependencyCheck{Name: "mongodb", Check: func(ctx context.Context) (string, error) {
+			return "", mongoClient.Ping(ctx)
+		}})
+	}
+	if fcmService != nil {
+		checks = append(checks, usecases.DependencyCheck{Name: "firebase_messaging", Check: func(ctx context.Context) (string, error) {
+			return "", fcmService.Ready()
+		}})
+	}
+	if elector != nil {
+		checks = append(checks, usecases.DependencyCheck{Name: "scheduler_lease", Check: func(ctx context.Context) (string, error) {
+			return elector.Status()
+		}})
+	}
+	return checks
+}
 
+// buildNotifiers registers a notifier for every channel. Webhooks need no
+// extra settings; push, email and Telegram fall back to a no-op notifier,
+// which marks deliveries skipped, when their transport is not configured.
+func buildNotifiers(cfg config.NotificationConfig, fcmService notifications.FCMService) []notifications.Notifier {
+	push := notifications.NewNoopNotifier(domain.ChannelPush)
+	if fcmService != nil {
+		push = notifications.NewFCMNotifier(fcmService)
+	}
+	notifiers := []notifications.Notifier{
+		push,
+		notifications.NewWebhookNotifier(cfg.WebhookSigningSecret, cfg.WebhookAllowPrivate),
+	}
+	if cfg.WebhookAllowPrivate {
+		slog.Warn("Webhooks may reach loopback and private addresses; use this only with local stub servers.")
+	}
 
+	if cfg.Email.Host != "" {
+		notifiers = append(notifiers, notifications.NewEmailNotifier(cfg.Email))
+		slog.Info("Email notifications enabled.")
+	} else {
+		notifiers = append(notifiers, notifications.NewNoopNotifier(domain.ChannelEmail))
+	}
+
+	if cfg.TelegramBotToken != "" {
+		notifiers = append(notifiers, notifications.NewTelegramNotifier(cfg.TelegramAPIBaseURL, cfg.TelegramBotToken))
+		slog.Info("Telegram notifications enabled.")
+	} else {
+		notifiers = append(notifiers, notifications.NewNoopNotifier(domain.ChannelTelegram))
+	}
+
+	return notifiers
+}
+
+// logNotifiers covers every channel in dev mode, logging what would have been sent.
+func logNotifiers() []notifications.Notifier {
+	var notifiers []notifications.Notifier
+	for _, channel := range []string{domain.ChannelPush, domain.ChannelWebhook, domain.ChannelEmail, domain.ChannelTelegram} {
+		notifiers = append(notifiers, notifications.NewLogNotifier(channel))
+	}
+	return notifiers
+}
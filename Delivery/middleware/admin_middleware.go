@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"consistent_1/Infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware admits requests whose X-Admin-Token header matches token.
+// An empty token disables the admin API entirely.
+func AdminMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": i18n.T(c.GetString("locale"), "error.admin_forbidden", nil)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
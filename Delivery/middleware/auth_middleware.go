@@ -1,12 +1,14 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	 
 	"consistent_1/Infrastructure/auth"   
+	"consistent_1/Infrastructure/i18n"
+	"consistent_1/Infrastructure/logging"
 
 	"github.com/gin-gonic/gin" 
 	"consistent_1/Domain"    
@@ -15,14 +17,14 @@ func AuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "error.auth_header_required", nil)})
 			c.Abort()
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.T(c.GetString("locale"), "error.auth_header_format", nil)})
 			c.Abort()
 			return
 		}
@@ -30,12 +32,13 @@ func AuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
 		tokenString := parts[1]
 		userID, err := jwtService.GetUserIDFromToken(tokenString)
 		if err != nil {
-			log.Printf("JWT validation failed: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrInvalidToken.Error()})
+			slog.WarnContext(c.Request.Context(), "JWT validation failed", "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": i18n.Error(c.GetString("locale"), domain.ErrInvalidToken)})
 			c.Abort()
 			return
 		}
 		c.Set("userID", userID)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), slog.String("user_id", userID)))
 		c.Next()
 	}
 }
\ No newline at end of file
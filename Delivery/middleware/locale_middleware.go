@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"consistent_1/Infrastructure/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware negotiates the response locale from Accept-Language and
+// stores it in the context under "locale".
+func LocaleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("locale", i18n.Negotiate(c.GetHeader("Accept-Language")))
+		c.Next()
+	}
+}
@@ -98,30 +98,62 @@
 package routers
 
 import (
+	"net/http"
+
 	"consistent_1/Delivery/controllers"
 	"consistent_1/Delivery/middleware"
 	"consistent_1/Infrastructure/auth"
+	"consistent_1/Infrastructure/metrics"
+	"consistent_1/Infrastructure/tracing"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// tracedRequest leaves probe and scrape traffic out of traces.
+func tracedRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
+
 func SetupRouter(
 	userController *controllers.UserController,
 	consistencyController *controllers.ConsistencyController,
+	notificationController *controllers.NotificationController,
+	reportController *controllers.ReportController,
+	achievementController *controllers.AchievementController,
+	adminController *controllers.AdminController,
+	healthController *controllers.HealthController,
 	jwtService auth.JWTService,
+	adminToken string,
+	corsOrigins []string,
 ) *gin.Engine {
-	// --- REVERTED: Use gin.Default() for Logger and Recovery middleware ---
-	router := gin.Default() // This includes gin.Logger() and gin.Recovery() by default
-	// --- END REVERTION ---
+	// gin.Logger's text lines are replaced by RequestIDMiddleware's structured access log.
+	router := gin.New()
+	router.Use(gin.Recovery())
+	// Tracing runs first so request logs and handler spans share its trace.
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(tracedRequest)))
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.MetricsMiddleware())
 
 	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000", "http://localhost:8080"} // React/Flutter web defaults or your specific frontend URL
+	config.AllowOrigins = corsOrigins // CORS_ALLOWED_ORIGINS, by default the React/Flutter web dev servers
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.RequestIDHeader}
+	config.ExposeHeaders = []string{"Content-Length", middleware.RequestIDHeader}
 	config.AllowCredentials = true
 	router.Use(cors.New(config))
+	router.Use(middleware.LocaleMiddleware())
+
+	// Probes for Render and load balancers; /status is for operators.
+	router.GET("/healthz", healthController.Healthz)
+	router.GET("/readyz", healthController.Readyz)
+	router.GET("/status", middleware.AdminMiddleware(adminToken), healthController.Status)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	publicRoutes := router.Group("/api/v1")
 	{
@@ -134,10 +166,30 @@ func SetupRouter(
 	{
 		authenticatedRoutes.GET("/profile", userController.GetUserProfile)
 		authenticatedRoutes.PATCH("/profile", userController.UpdateUserProfile)
+		authenticatedRoutes.GET("/profile/notifications", userController.GetNotificationSettings)
+		authenticatedRoutes.PUT("/profile/notifications", userController.UpdateNotificationSettings)
+		authenticatedRoutes.DELETE("/devices/:token", userController.RemoveDevice)
 		authenticatedRoutes.GET("/consistency", consistencyController.GetDailyConsistency)           // Can take 'date' query param
 		authenticatedRoutes.GET("/consistency/history", consistencyController.GetConsistencyHistory) // Takes 'startDate', 'endDate' query params
 		authenticatedRoutes.GET("/consistency/streaks", consistencyController.GetUserStreaks)
 		authenticatedRoutes.POST("/consistency/check", consistencyController.TriggerDailyConsistencyCheck) // Manual trigger for debugging
+		authenticatedRoutes.GET("/notifications", notificationController.GetNotificationHistory)           // Takes 'limit', 'offset' query params
+		authenticatedRoutes.GET("/reports/weekly", reportController.GetWeeklyReport)                       // Takes 'week' query param, e.g. 2026-W42
+		authenticatedRoutes.GET("/reports/monthly", reportController.GetMonthlyReport)                     // Takes 'month' query param, e.g. 2026-10
+		authenticatedRoutes.GET("/achievements", achievementController.GetAchievements)
+		authenticatedRoutes.GET("/inbox", notificationController.GetInbox) // Takes 'limit', 'offset' query params
+		authenticatedRoutes.POST("/inbox/read-all", notificationController.MarkAllInboxItemsRead)
+		authenticatedRoutes.POST("/inbox/:id/read", notificationController.MarkInboxItemRead)
+	}
+
+	adminRoutes := router.Group("/api/v1/admin")
+	adminRoutes.Use(middleware.AdminMiddleware(adminToken))
+	{
+		adminRoutes.GET("/platforms", adminController.GetPlatformStatuses)
+		adminRoutes.GET("/jobs", adminController.ListJobs)
+		adminRoutes.GET("/jobs/:id", adminController.GetJob)
+		adminRoutes.POST("/jobs/:id/requeue", adminController.RequeueJob)
+		adminRoutes.GET("/job-runs", adminController.ListJobRuns)
 	}
 
 	return router
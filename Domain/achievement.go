@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Metrics an achievement rule can be measured against.
+const (
+	MetricLongestStreak       = "longestStreak"
+	MetricTotalSolved         = "totalSolved"
+	MetricHardSolved          = "hardSolved"
+	MetricMultiPlatformStreak = "multiPlatformStreak" // consecutive days consistent on two or more platforms
+)
+
+// AchievementRule unlocks once the user's value for Metric reaches Threshold.
+type AchievementRule struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"`
+	Threshold   int    `json:"threshold"`
+}
+
+// AchievementRules is the catalogue evaluated after every consistency check.
+// IDs are persisted, so existing ones must never be renamed.
+var AchievementRules = []AchievementRule{
+	{ID: "streak_3", Title: "Warming Up", Description: "Stay consistent for 3 days in a row.", Metric: MetricLongestStreak, Threshold: 3},
+	{ID: "streak_7", Title: "One Week Strong", Description: "Stay consistent for 7 days in a row.", Metric: MetricLongestStreak, Threshold: 7},
+	{ID: "streak_30", Title: "Monthly Machine", Description: "Stay consistent for 30 days in a row.", Metric: MetricLongestStreak, Threshold: 30},
+	{ID: "streak_100", Title: "Centurion", Description: "Stay consistent for 100 days in a row.", Metric: MetricLongestStreak, Threshold: 100},
+	{ID: "streak_365", Title: "Year of Code", Description: "Stay consistent for 365 days in a row.", Metric: MetricLongestStreak, Threshold: 365},
+	{ID: "solved_1", Title: "First Steps", Description: "Solve your first problem.", Metric: MetricTotalSolved, Threshold: 1},
+	{ID: "solved_50", Title: "Half Century", Description: "Solve 50 problems.", Metric: MetricTotalSolved, Threshold: 50},
+	{ID: "solved_100", Title: "Triple Digits", Description: "Solve 100 problems.", Metric: MetricTotalSolved, Threshold: 100},
+	{ID: "solved_500", Title: "Problem Crusher", Description: "Solve 500 problems.", Metric: MetricTotalSolved, Threshold: 500},
+	{ID: "solved_1000", Title: "Thousand Club", Description: "Solve 1000 problems.", Metric: MetricTotalSolved, Threshold: 1000},
+	{ID: "first_hard", Title: "Going Hard", Description: "Solve your first Hard problem.", Metric: MetricHardSolved, Threshold: 1},
+	{ID: "hard_25", Title: "Hardened", Description: "Solve 25 Hard problems.", Metric: MetricHardSolved, Threshold: 25},
+	{ID: "dual_platform_7", Title: "Two-Platform Week", Description: "Be consistent on two platforms for 7 days in a row.", Metric: MetricMultiPlatformStreak, Threshold: 7},
+}
+
+// AchievementStats holds the metric values rules are evaluated against.
+type AchievementStats map[string]int
+
+// Met reports whether the stats satisfy the rule.
+func (r AchievementRule) Met(stats AchievementStats) bool {
+	return stats[r.Metric] >= r.Threshold
+}
+
+// Achievement is an unlocked rule persisted for a user.
+type Achievement struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID        primitive.ObjectID `bson:"userId" json:"-"`
+	AchievementID string             `bson:"achievementId" json:"achievementId"`
+	UnlockedAt    time.Time          `bson:"unlockedAt" json:"unlockedAt"`
+	Announced     bool               `bson:"announced" json:"-"`
+}
+
+// AchievementStatus is a catalogue entry together with the user's progress on it.
+type AchievementStatus struct {
+	AchievementRule
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlockedAt,omitempty"`
+	Progress   int        `json:"progress"`
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,6 +12,9 @@ type DailyConsistency struct {
 	Date               time.Time          `bson:"date" json:"date"`                        
 	PlatformActivities []PlatformActivity `bson:"platformActivities" json:"platformActivities"` 
 	OverallConsistent  bool               `bson:"overallConsistent" json:"overallConsistent"` // True if user met overall daily goal (e.g., solved at least one problem on any platform)
+	SyncStatus         string             `bson:"syncStatus,omitempty" json:"syncStatus,omitempty"` // worst platform sync status, see SettleSyncStatus
+	SyncAttempts       int                `bson:"syncAttempts,omitempty" json:"-"`
+	NextSyncAt         time.Time          `bson:"nextSyncAt,omitempty" json:"-"`
 	CreatedAt          time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt          time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
@@ -24,6 +28,46 @@ func (dc *DailyConsistency) GetPlatformActivity(platformName string) PlatformAct
 	return PlatformActivity{}
 }
 
+// SettleSyncStatus derives the day's sync status from its platforms. A day
+// that is already consistent is known regardless of the platforms that failed.
+func (dc *DailyConsistency) SettleSyncStatus() {
+	dc.SyncStatus = SyncStatusOK
+	if dc.OverallConsistent {
+		return
+	}
+	for _, activity := range dc.PlatformActivities {
+		switch activity.SyncStatus {
+		case SyncStatusFailed:
+			dc.SyncStatus = SyncStatusFailed
+		case SyncStatusPending:
+			if dc.SyncStatus == SyncStatusOK {
+				dc.SyncStatus = SyncStatusPending
+			}
+		}
+	}
+}
+
+// Unknown reports whether the day is not consistent only because a platform
+// could not be checked. Unknown days neither extend nor break a streak, except
+// at its end, where they only hold it open for UnknownDayGraceDays.
+func (dc *DailyConsistency) Unknown() bool {
+	return !dc.OverallConsistent && dc.SyncStatus != "" && dc.SyncStatus != SyncStatusOK
+}
+
+// StreakDay returns the day that consistency records and streaks count t
+// towards. Streak days run from midnight to midnight UTC for every user,
+// whatever their timezone.
+func StreakDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// UnknownDayGraceDays is how many days, counting today, unknown days after the
+// last consistent day keep the current streak alive. Past days are never
+// re-checked, so a longer run of them ends the streak; otherwise a handle that
+// keeps failing would hold it open forever. Unknown days followed by a
+// consistent day bridge the gap however old they are.
+const UnknownDayGraceDays = 3
+
 
 
 type StreakInfo struct {
@@ -32,9 +76,104 @@ type StreakInfo struct {
 	LastConsistentDay *time.Time `json:"lastConsistentDay,omitempty"`
 }
 
+// ComputeStreaks derives the streaks from a user's history sorted by date, as
+// they stand on the streak day today. Records after today are ignored.
+func ComputeStreaks(consistencies []DailyConsistency, today time.Time) *StreakInfo {
+	streakInfo := &StreakInfo{}
+	if len(consistencies) == 0 {
+		return streakInfo
+	}
+
+	var consistentDays []time.Time
+	unknownDays := make(map[time.Time]bool)
+	for _, dc := range consistencies {
+		if dc.Date.After(today) {
+			break
+		}
+		if dc.OverallConsistent {
+			consistentDays = append(consistentDays, dc.Date)
+		} else if dc.Unknown() {
+			unknownDays[dc.Date] = true
+		}
+	}
+
+	if len(consistentDays) == 0 {
+		return streakInfo
+	}
+
+	// Days a platform could not be checked bridge the gap between consistent
+	// days instead of breaking the streak.
+	connected := func(earlier, later time.Time) bool {
+		for day := earlier.AddDate(0, 0, 1); day.Before(later); day = day.AddDate(0, 0, 1) {
+			if !unknownDays[day] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var longestStreak int
+	currentCount := 0
+	for i := 0; i < len(consistentDays); i++ {
+		if i == 0 || connected(consistentDays[i-1], consistentDays[i]) {
+			currentCount++
+		} else {
+			currentCount = 1
+		}
+		if currentCount > longestStreak {
+			longestStreak = currentCount
+		}
+	}
+	mostRecentConsistentDay := consistentDays[len(consistentDays)-1]
+	streakInfo.LastConsistentDay = &mostRecentConsistentDay
+
+	// The streak is still alive if nothing but today, or unknown days still
+	// within the grace period, lies between the last consistent day and now.
+	settled := today.AddDate(0, 0, -UnknownDayGraceDays)
+	actualCurrentStreak := 0
+	if !mostRecentConsistentDay.Before(today) ||
+		(!mostRecentConsistentDay.Before(settled) && connected(mostRecentConsistentDay, today)) {
+		actualCurrentStreak = 1
+		for i := len(consistentDays) - 2; i >= 0; i-- {
+			if connected(consistentDays[i], consistentDays[i+1]) {
+				actualCurrentStreak++
+			} else {
+				break
+			}
+		}
+	}
+
+	streakInfo.CurrentStreak = actualCurrentStreak
+	streakInfo.LongestStreak = longestStreak
+
+	return streakInfo
+}
+
+// StreakAtEndOf returns the current streak as it stood once the streak day
+// was over, from a user's history sorted by date.
+func StreakAtEndOf(consistencies []DailyConsistency, day time.Time) int {
+	next := day.AddDate(0, 0, 1)
+	end := sort.Search(len(consistencies), func(i int) bool {
+		return !consistencies[i].Date.Before(next)
+	})
+	return ComputeStreaks(consistencies[:end], next).CurrentStreak
+}
+
 
 type ConsistencyFilter struct {
 	UserID    primitive.ObjectID
 	StartDate *time.Time
 	EndDate   *time.Time
-}
\ No newline at end of file
+}
+
+// ConsistencyRunSummary describes one pass of the nightly consistency check.
+type ConsistencyRunSummary struct {
+	Total      int           `json:"total"`
+	Checked    int           `json:"checked"`    // users whose check ran, successfully or not
+	Consistent int           `json:"consistent"` // checked users who were consistent
+	Failed     int           `json:"failed"`
+	Skipped    int           `json:"skipped"` // users never reached because the run was cancelled
+	Cancelled  bool          `json:"cancelled"`
+	StartedAt  time.Time     `json:"startedAt"`
+	Duration   time.Duration `json:"duration"`
+}
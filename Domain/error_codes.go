@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 var (
 	ErrUserNotFound          = errors.New("user not found")
 	ErrInvalidCredentials    = errors.New("invalid credentials")
@@ -14,7 +17,46 @@ var (
 	ErrExternalAPIFailed     = errors.New("external platform API failed")
 	ErrProcessingConsistency = errors.New("error processing consistency data")
 	ErrInvalidNotificationTime = errors.New("invalid notification time format, expected HH:MM")
+	ErrUnsupportedPlatform     = errors.New("unsupported platform")
+	ErrPlatformUserNotFound    = errors.New("platform user not found")
+	ErrDeviceNotFound          = errors.New("device not found")
+	ErrDeviceTokenInvalid      = errors.New("device token is unregistered or invalid")
+	ErrUnsupportedChannel      = errors.New("unsupported notification channel")
+	ErrInvalidWebhookURL       = errors.New("invalid webhook URL, expected an absolute http or https URL")
+	ErrRecipientNotConfigured  = errors.New("recipient has no address configured for this channel")
+	ErrNotificationDuplicate   = errors.New("notification already queued for this user today")
+	ErrNotificationSuppressed  = errors.New("notification suppressed by user preferences")
+	ErrUnsupportedNotificationKind = errors.New("unsupported notification kind")
+	ErrInvalidQuietHours       = errors.New("invalid quiet hours, expected distinct HH:MM start and end")
+	ErrInvalidReportPeriod     = errors.New("invalid report period")
+	ErrAchievementAlreadyUnlocked = errors.New("achievement already unlocked")
+	ErrInboxItemNotFound       = errors.New("inbox item not found")
+	ErrInboxItemDuplicate      = errors.New("notification already in the inbox")
+	ErrUnsupportedLocale       = errors.New("unsupported locale")
+	ErrPlatformUnavailable     = errors.New("platform temporarily unavailable, circuit breaker open")
+	ErrLeaseHeld               = errors.New("lease held by another process")
+	ErrJobNotFound             = errors.New("job not found")
+	ErrJobDuplicate            = errors.New("job already queued")
+	ErrJobNotRequeueable       = errors.New("only failed or dead jobs can be re-enqueued")
+	ErrUnsupportedJobType      = errors.New("unsupported job type")
+	ErrPlatformDisabled        = errors.New("platform is not enabled on this server")
+	ErrChannelNotConfigured    = errors.New("notification channel not configured on this server")
 )
 
+// PlatformHandleError reports which linked handle failed validation and why.
+type PlatformHandleError struct {
+	Platform string
+	Username string
+	Err      error
+}
+
+func (e *PlatformHandleError) Error() string {
+	return fmt.Sprintf("%s handle %q: %v", e.Platform, e.Username, e.Err)
+}
+
+func (e *PlatformHandleError) Unwrap() error {
+	return e.Err
+}
+
 
 
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Dependency states reported by the readiness probe.
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// DependencyStatus is the outcome of probing one dependency.
+type DependencyStatus struct {
+	Name    string        `json:"name"`
+	Status  string        `json:"status"`
+	Detail  string        `json:"detail,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Readiness reports whether the process can serve traffic, i.e. every
+// dependency probe succeeded.
+type Readiness struct {
+	Ready        bool               `json:"ready"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+	CheckedAt    time.Time          `json:"checkedAt"`
+}
+
+// QueueDepth counts background work waiting to be done. Jobs are keyed by
+// status, e.g. "queued", "failed" or "dead".
+type QueueDepth struct {
+	Jobs                 map[string]int64 `json:"jobs"`
+	PendingNotifications int64            `json:"pendingNotifications"`
+}
+
+// SystemStatus is the operator summary served at /status.
+type SystemStatus struct {
+	Readiness
+	LastNightlyRun *JobRun          `json:"lastNightlyRun"`
+	SchedulerLease *Lease           `json:"schedulerLease"`
+	Platforms      []PlatformStatus `json:"platforms"`
+	Queue          QueueDepth       `json:"queue"`
+}
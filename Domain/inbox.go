@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InboxItem is the in-app copy of a notification. It is written whenever a
+// notification is raised, including one held back by quiet hours, and kept
+// whether or not any channel delivers it. DedupKey matches the outbox record's;
+// NotificationID is zero when the notification was never queued for delivery.
+type InboxItem struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"userId" json:"-"`
+	NotificationID primitive.ObjectID `bson:"notificationId,omitempty" json:"notificationId"`
+	Type           string             `bson:"type" json:"type"`
+	Kind           string             `bson:"kind,omitempty" json:"kind,omitempty"`
+	Title          string             `bson:"title" json:"title"`
+	Body           string             `bson:"body" json:"body"`
+	Data           map[string]string  `bson:"data,omitempty" json:"data,omitempty"`
+	DedupKey       string             `bson:"dedupKey,omitempty" json:"-"`
+	Read           bool               `bson:"read" json:"read"`
+	ReadAt         *time.Time         `bson:"readAt,omitempty" json:"readAt,omitempty"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// InboxPage is one page of a user's inbox, newest first.
+type InboxPage struct {
+	Items       []InboxItem `json:"items"`
+	UnreadCount int64       `json:"unreadCount"`
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job types run by the job worker.
+const (
+	JobTypeDailyConsistencyCheck = "daily_consistency_check"
+	JobTypeDispatchReminders     = "dispatch_reminders"
+	JobTypeDispatchEscalations   = "dispatch_streak_escalations"
+)
+
+// Job states. A failed job is waiting for its next attempt; a dead one has
+// used up its attempts and only runs again if an admin re-enqueues it.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusDead      = "dead"
+)
+
+// Job run outcomes. A run is abandoned when its worker died mid-run and the
+// job's lease expired.
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+	JobRunAbandoned = "abandoned"
+)
+
+// Job is a unit of queued background work. Workers lease a job before running
+// it, so a job whose worker dies is picked up again once the lease expires.
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Payload     map[string]string  `bson:"payload,omitempty" json:"payload,omitempty"`
+	DedupKey    string             `bson:"dedupKey,omitempty" json:"dedupKey,omitempty"` // unique when set
+	Status      string             `bson:"status" json:"status"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	MaxAttempts int                `bson:"maxAttempts" json:"maxAttempts"`
+	RunAt       time.Time          `bson:"runAt" json:"runAt"`
+	LastError   string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	LeaseID     string             `bson:"leaseId,omitempty" json:"-"`
+	LeaseUntil  time.Time          `bson:"leaseUntil,omitempty" json:"leaseUntil,omitempty"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// JobRun records one attempt at running a job.
+type JobRun struct {
+	ID         primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	JobID      primitive.ObjectID     `bson:"jobId" json:"jobId"`
+	Type       string                 `bson:"type" json:"type"`
+	Attempt    int                    `bson:"attempt" json:"attempt"`
+	Worker     string                 `bson:"worker" json:"worker"`
+	Status     string                 `bson:"status" json:"status"`
+	Error      string                 `bson:"error,omitempty" json:"error,omitempty"`
+	Result     map[string]interface{} `bson:"result,omitempty" json:"result,omitempty"`
+	StartedAt  time.Time              `bson:"startedAt" json:"startedAt"`
+	FinishedAt *time.Time             `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	Duration   time.Duration          `bson:"duration,omitempty" json:"duration,omitempty"`
+}
+
+// JobFilter narrows job and run listings; empty fields match everything.
+type JobFilter struct {
+	Type   string
+	Status string
+	JobID  primitive.ObjectID
+	Limit  int64
+	Offset int64
+}
+
+// JobDetail is a job together with its run history, newest run first.
+type JobDetail struct {
+	Job
+	Runs []JobRun `json:"runs"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// SchedulerLeaseName is the lease a process must hold to run scheduled jobs.
+const SchedulerLeaseName = "scheduler"
+
+// MigrationsLeaseName is the lease a process must hold to apply schema
+// migrations, so replicas starting together don't run a step twice.
+const MigrationsLeaseName = "schema_migrations"
+
+// Lease grants one process the exclusive right to act for Name until
+// ExpiresAt. Token is a fencing token: it grows every time the lease changes
+// hands, so a holder that stalled past expiry can tell it has been replaced.
+type Lease struct {
+	Name       string    `bson:"_id" json:"name"`
+	Holder     string    `bson:"holder" json:"holder"`
+	Token      int64     `bson:"token" json:"token"`
+	AcquiredAt time.Time `bson:"acquiredAt" json:"acquiredAt"`
+	ExpiresAt  time.Time `bson:"expiresAt" json:"expiresAt"`
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification and per-channel delivery states.
+const (
+	NotificationStatusPending   = "pending"
+	NotificationStatusSent      = "sent"
+	NotificationStatusPartial   = "partial" // some channels delivered, others failed for good
+	NotificationStatusFailed    = "failed"
+	NotificationStatusSkipped   = "skipped"   // channel unavailable or recipient has no address for it
+	NotificationStatusCancelled = "cancelled" // no longer relevant, e.g. the user became consistent first
+)
+
+// NotificationRecord is an outbox entry: one notification for one user, fanned
+// out to each of the user's channels and retried until every channel settles.
+type NotificationRecord struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"userId" json:"userId"`
+	Type          string             `bson:"type" json:"type"`
+	Kind          string             `bson:"kind,omitempty" json:"kind,omitempty"`
+	Title         string             `bson:"title" json:"title"`
+	Body          string             `bson:"body" json:"body"`
+	HTMLBody      string             `bson:"htmlBody,omitempty" json:"-"`
+	Data          map[string]string  `bson:"data,omitempty" json:"data,omitempty"`
+	LocalDate     string             `bson:"localDate" json:"localDate"` // YYYY-MM-DD in the user's timezone when enqueued
+	DedupKey      string             `bson:"dedupKey" json:"-"`          // unique; defaults to userId/type/localDate
+	Channels      []ChannelDelivery  `bson:"channels" json:"channels"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"-"`
+	LeaseID       string             `bson:"leaseId,omitempty" json:"-"`
+	LeaseUntil    time.Time          `bson:"leaseUntil,omitempty" json:"-"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ChannelDelivery tracks delivery of a NotificationRecord over one channel.
+type ChannelDelivery struct {
+	Channel   string     `bson:"channel" json:"channel"`
+	Status    string     `bson:"status" json:"status"`
+	Attempts  int        `bson:"attempts" json:"attempts"`
+	LastError string     `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	SentAt    *time.Time `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+}
+
+// SettleStatus derives the record's overall status from its channels.
+func (n *NotificationRecord) SettleStatus() string {
+	sent, failed := 0, 0
+	for _, ch := range n.Channels {
+		switch ch.Status {
+		case NotificationStatusPending:
+			return NotificationStatusPending
+		case NotificationStatusSent:
+			sent++
+		case NotificationStatusFailed:
+			failed++
+		}
+	}
+	switch {
+	case failed == 0 && sent == 0:
+		return NotificationStatusSkipped
+	case failed == 0:
+		return NotificationStatusSent
+	case sent > 0:
+		return NotificationStatusPartial
+	default:
+		return NotificationStatusFailed
+	}
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"time"
+)
+
+// Notification kinds users can toggle independently.
+const (
+	NotificationKindReminder       = "reminder"
+	NotificationKindMilestone      = "milestone"
+	NotificationKindWeeklyDigest   = "weekly_digest"
+	NotificationKindMonthlyDigest  = "monthly_digest"
+	NotificationKindFriendActivity = "friend_activity"
+)
+
+// SupportedNotificationKinds lists the keys accepted in NotificationPreferences.Types.
+var SupportedNotificationKinds = map[string]bool{
+	NotificationKindReminder:       true,
+	NotificationKindMilestone:      true,
+	NotificationKindWeeklyDigest:   true,
+	NotificationKindMonthlyDigest:  true,
+	NotificationKindFriendActivity: true,
+}
+
+// NotificationPreferences holds a user's quiet hours and per-kind settings.
+// Kinds missing from Types are enabled on the user's default channels.
+type NotificationPreferences struct {
+	QuietHours []QuietHoursWindow        `bson:"quietHours,omitempty" json:"quietHours"`
+	Types      map[string]KindPreference `bson:"types,omitempty" json:"types"`
+}
+
+// QuietHoursWindow silences notifications between Start and End, both HH:MM in
+// the user's timezone. A window whose End is before its Start wraps past midnight.
+type QuietHoursWindow struct {
+	Start string `bson:"start" json:"start"`
+	End   string `bson:"end" json:"end"`
+}
+
+// KindPreference toggles one notification kind and optionally overrides the
+// channels it is delivered on.
+type KindPreference struct {
+	Enabled  bool     `bson:"enabled" json:"enabled"`
+	Channels []string `bson:"channels,omitempty" json:"channels,omitempty"`
+}
+
+// NotificationSettings is the request and response body of /profile/notifications.
+type NotificationSettings struct {
+	Channels   []string                  `json:"channels"`
+	QuietHours []QuietHoursWindow        `json:"quietHours"`
+	Types      map[string]KindPreference `json:"types"`
+}
+
+// Validate checks every window, kind and channel in the settings.
+func (s *NotificationSettings) Validate() error {
+	for _, channel := range s.Channels {
+		if !SupportedChannels[channel] {
+			return ErrUnsupportedChannel
+		}
+	}
+	for _, w := range s.QuietHours {
+		start, errStart := time.Parse("15:04", w.Start)
+		end, errEnd := time.Parse("15:04", w.End)
+		if errStart != nil || errEnd != nil || start.Equal(end) {
+			return ErrInvalidQuietHours
+		}
+	}
+	for kind, pref := range s.Types {
+		if !SupportedNotificationKinds[kind] {
+			return ErrUnsupportedNotificationKind
+		}
+		for _, channel := range pref.Channels {
+			if !SupportedChannels[channel] {
+				return ErrUnsupportedChannel
+			}
+		}
+	}
+	return nil
+}
+
+// KindEnabled reports whether the user wants notifications of the given kind.
+func (u *User) KindEnabled(kind string) bool {
+	pref, ok := u.NotificationPreferences.Types[kind]
+	return !ok || pref.Enabled
+}
+
+// ChannelsFor returns the channels a notification of the given kind goes out
+// on: the kind's override if set, otherwise the user's enabled channels.
+func (u *User) ChannelsFor(kind string) []string {
+	if pref, ok := u.NotificationPreferences.Types[kind]; ok && len(pref.Channels) > 0 {
+		return pref.Channels
+	}
+	return u.EnabledChannels()
+}
+
+// QuietUntil reports whether t falls inside one of the user's quiet-hour
+// windows and, if so, the instant the silence ends.
+func (u *User) QuietUntil(t time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	minuteOfDay := local.Hour()*60 + local.Minute()
+	at := func(days, minutes int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, minutes/60, minutes%60, 0, 0, loc)
+	}
+
+	var until time.Time
+	for _, w := range u.NotificationPreferences.QuietHours {
+		start, errStart := time.Parse("15:04", w.Start)
+		end, errEnd := time.Parse("15:04", w.End)
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+		startMin := start.Hour()*60 + start.Minute()
+		endMin := end.Hour()*60 + end.Minute()
+
+		var windowEnd time.Time
+		switch {
+		case startMin < endMin && minuteOfDay >= startMin && minuteOfDay < endMin:
+			windowEnd = at(0, endMin)
+		case startMin > endMin && minuteOfDay >= startMin:
+			windowEnd = at(1, endMin)
+		case startMin > endMin && minuteOfDay < endMin:
+			windowEnd = at(0, endMin)
+		default:
+			continue
+		}
+		if windowEnd.After(until) {
+			until = windowEnd
+		}
+	}
+	return until.UTC(), !until.IsZero()
+}
@@ -21,6 +21,54 @@ type PlatformActivity struct {
 	Username       string    `bson:"username" json:"username"`           
 	Date           time.Time `bson:"date" json:"date"`                    
 	ProblemsSolved int       `bson:"problemsSolved" json:"problemsSolved"`
+	HardSolved     int       `bson:"hardSolved,omitempty" json:"hardSolved,omitempty"` // Hard-rated problems among ProblemsSolved
 	IsConsistent   bool      `bson:"isConsistent" json:"isConsistent"`    
+	SyncStatus     string    `bson:"syncStatus,omitempty" json:"syncStatus,omitempty"` // empty on records written before sync tracking, meaning ok
+	SyncError      string    `bson:"syncError,omitempty" json:"-"`
 }
 
+// Platform sync states. Only SyncStatusOK means IsConsistent and
+// ProblemsSolved reflect what the platform reported.
+const (
+	SyncStatusOK      = "ok"
+	SyncStatusFailed  = "failed"  // the fetch errored; retried while the day is open
+	SyncStatusPending = "pending" // not fetched, e.g. because the platform's circuit breaker is open
+)
+
+// Synced reports whether the activity was actually fetched from the platform.
+func (a PlatformActivity) Synced() bool {
+	return a.SyncStatus == "" || a.SyncStatus == SyncStatusOK
+}
+
+// SupportedPlatforms lists the keys accepted in User.PlatformUsernames.
+var SupportedPlatforms = map[string]bool{
+	"leetcode":   true,
+	"codeforces": true,
+}
+
+// Circuit breaker states reported in PlatformStatus.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// PlatformStatus is the outbound protection state for one coding platform.
+type PlatformStatus struct {
+	Platform            string     `json:"platform"`
+	Breaker             string     `json:"breaker"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+	RequestsPerSecond   float64    `json:"requestsPerSecond"`
+	Burst               int        `json:"burst"`
+	// Requests that reached the platform in the last WindowMinutes, and how
+	// many of them failed after retries.
+	WindowMinutes  int     `json:"windowMinutes"`
+	RecentRequests int     `json:"recentRequests"`
+	RecentFailures int     `json:"recentFailures"`
+	ErrorRate      float64 `json:"errorRate"`
+	// Disabled is set for a platform turned off in configuration; the other
+	// fields are then zero.
+	Disabled bool `json:"disabled,omitempty"`
+}
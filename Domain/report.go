@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+)
+
+// Report periods.
+const (
+	ReportPeriodWeekly  = "weekly"
+	ReportPeriodMonthly = "monthly"
+)
+
+// ProgressReport summarises a user's activity over one ISO week or calendar month.
+type ProgressReport struct {
+	Period             string         `json:"period"`
+	Label              string         `json:"label"` // e.g. "2026-W42" or "2026-10"
+	StartDate          time.Time      `json:"startDate"`
+	EndDate            time.Time      `json:"endDate"`
+	TotalDays          int            `json:"totalDays"`
+	DaysConsistent     int            `json:"daysConsistent"`
+	TotalProblems      int            `json:"totalProblems"`
+	ProblemsByPlatform map[string]int `json:"problemsByPlatform"`
+	BestDay            *DaySummary    `json:"bestDay,omitempty"`
+	StreakAtStart      int            `json:"streakAtStart"`
+	StreakAtEnd        int            `json:"streakAtEnd"`
+	StreakChange       int            `json:"streakChange"`
+	LongestStreak      int            `json:"longestStreak"`
+	Previous           *PeriodTotals  `json:"previous,omitempty"`
+}
+
+// DaySummary is the activity recorded on a single day.
+type DaySummary struct {
+	Date           time.Time `json:"date"`
+	ProblemsSolved int       `json:"problemsSolved"`
+}
+
+// PeriodTotals are the headline numbers of the preceding period, with the
+// change from them to the reported period.
+type PeriodTotals struct {
+	Label               string `json:"label"`
+	DaysConsistent      int    `json:"daysConsistent"`
+	TotalProblems       int    `json:"totalProblems"`
+	DaysConsistentDelta int    `json:"daysConsistentDelta"`
+	TotalProblemsDelta  int    `json:"totalProblemsDelta"`
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,13 +14,167 @@ type User struct {
 	PlatformUsernames         map[string]string  `bson:"platformUsernames" json:"platformUsernames"` 
 	NotificationTime          string             `bson:"notificationTime" json:"notificationTime"`  
 	Timezone                  string             `bson:"timezone" json:"timezone"`                   
-	FCMTokens                 []string           `bson:"fcmTokens,omitempty" json:"fcmTokens,omitempty"` 
+	NextReminderAt            time.Time          `bson:"nextReminderAt,omitempty" json:"nextReminderAt,omitempty"` // UTC instant the next reminder is due
+	NextEscalationAt          time.Time          `bson:"nextEscalationAt,omitempty" json:"-"`                        // UTC instant the next streak-at-risk follow-up is due
+	NextWeeklyDigestAt        time.Time          `bson:"nextWeeklyDigestAt,omitempty" json:"-"`
+	NextMonthlyDigestAt       time.Time          `bson:"nextMonthlyDigestAt,omitempty" json:"-"`
+	NotificationChannels      []string           `bson:"notificationChannels,omitempty" json:"notificationChannels,omitempty"` // empty means push only
+	TelegramChatID            string             `bson:"telegramChatId,omitempty" json:"telegramChatId,omitempty"`
+	WebhookURL                string             `bson:"webhookUrl,omitempty" json:"webhookUrl,omitempty"`
+	NotificationPreferences   NotificationPreferences `bson:"notificationPreferences,omitempty" json:"notificationPreferences"`
+	Locale                    string             `bson:"locale,omitempty" json:"locale,omitempty"` // empty means the default locale
+	Devices                   []Device           `bson:"devices,omitempty" json:"devices,omitempty"`
+	FCMTokens                 []string           `bson:"fcmTokens,omitempty" json:"-"` // Deprecated: legacy bare tokens, superseded by Devices
 	CreatedAt                 time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt                 time.Time          `bson:"updatedAt" json:"updatedAt"`
 	LeetCodeLastTotalSolved int       `bson:"leetcodeLastTotalSolved,omitempty" json:"leetcodeLastTotalSolved,omitempty"`
 	LeetCodeLastCheckDate   time.Time `bson:"leetcodeLastCheckDate,omitempty" json:"leetcodeLastCheckDate,omitempty"`
+	LeetCodeLastHardSolved  int       `bson:"leetcodeLastHardSolved,omitempty" json:"leetcodeLastHardSolved,omitempty"`
 	
 }
+
+// Notification channels a user can opt into.
+const (
+	ChannelPush     = "push"
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+)
+
+// SupportedChannels lists the values accepted in User.NotificationChannels.
+var SupportedChannels = map[string]bool{
+	ChannelPush:     true,
+	ChannelEmail:    true,
+	ChannelTelegram: true,
+	ChannelWebhook:  true,
+}
+
+// EnabledChannels returns the channels the user wants notifications on,
+// defaulting to push for users who never chose.
+func (u *User) EnabledChannels() []string {
+	if len(u.NotificationChannels) == 0 {
+		return []string{ChannelPush}
+	}
+	return u.NotificationChannels
+}
+
+// Device is a push-capable client registered by the user.
+type Device struct {
+	Token      string    `bson:"token" json:"token"`
+	Platform   string    `bson:"platform,omitempty" json:"platform,omitempty"` // e.g. "android", "ios", "web"
+	AppVersion string    `bson:"appVersion,omitempty" json:"appVersion,omitempty"`
+	LastSeen   time.Time `bson:"lastSeen" json:"lastSeen"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+}
+
+// DeviceTokens returns the FCM tokens of all registered devices, including
+// legacy tokens stored before device records existed.
+func (u *User) DeviceTokens() []string {
+	seen := make(map[string]bool, len(u.Devices)+len(u.FCMTokens))
+	tokens := make([]string, 0, len(u.Devices)+len(u.FCMTokens))
+	for _, d := range u.Devices {
+		if d.Token != "" && !seen[d.Token] {
+			seen[d.Token] = true
+			tokens = append(tokens, d.Token)
+		}
+	}
+	for _, t := range u.FCMTokens {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// ScheduleParked is stored in a schedule field when the user's settings cannot
+// produce a due instant, so the dispatchers stop polling the user every minute.
+// Fixing the settings through a profile update reschedules them.
+var ScheduleParked = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// NextReminderAfter returns the first instant strictly after t at which the
+// user's NotificationTime occurs in their Timezone, expressed in UTC.
+func (u *User) NextReminderAfter(t time.Time) (time.Time, error) {
+	clock, err := time.Parse("15:04", u.NotificationTime)
+	if err != nil {
+		return time.Time{}, ErrInvalidNotificationTime
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := t.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if !next.After(t) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+	return next.UTC(), nil
+}
+
+// NextEscalationAfter returns the first instant strictly after t that lies one
+// of offsets before the end of a streak day, which is UTC midnight.
+func NextEscalationAfter(t time.Time, offsets []time.Duration) (time.Time, error) {
+	day := StreakDay(t)
+	var next time.Time
+	for days := 1; days <= 2; days++ {
+		end := day.AddDate(0, 0, days)
+		for _, offset := range offsets {
+			candidate := end.Add(-offset)
+			if candidate.After(t) && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+		if !next.IsZero() {
+			return next, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no escalation offsets configured")
+}
+
+// NextDigestAfter returns the first instant strictly after t at which a digest
+// of the given period is due: Sundays at clock (HH:MM) for weekly digests, and
+// the last day of each month at clock for monthly ones. The result is in UTC.
+func (u *User) NextDigestAfter(t time.Time, period, clock string) (time.Time, error) {
+	at, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := t.In(loc)
+	for i := 0; i < 2; i++ {
+		var candidate time.Time
+		switch period {
+		case ReportPeriodWeekly:
+			daysUntilSunday := (7 - int(local.Weekday())) % 7
+			candidate = time.Date(local.Year(), local.Month(), local.Day()+daysUntilSunday+7*i, at.Hour(), at.Minute(), 0, 0, loc)
+		case ReportPeriodMonthly:
+			// Day 0 of the following month is the last day of this one.
+			candidate = time.Date(local.Year(), local.Month()+time.Month(i)+1, 0, at.Hour(), at.Minute(), 0, 0, loc)
+		default:
+			return time.Time{}, fmt.Errorf("unknown report period %q", period)
+		}
+		if candidate.After(t) {
+			return candidate.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no upcoming %s digest after %s", period, t)
+}
+
+// LocalDate returns the calendar date of t in the user's timezone as YYYY-MM-DD,
+// falling back to UTC when the timezone cannot be loaded.
+func (u *User) LocalDate(t time.Time) string {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("2006-01-02")
+}
+
 type UserLoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -31,13 +186,39 @@ type UserRegisterRequest struct {
 	Username         string `json:"username" binding:"required,min=3"`
 	NotificationTime string `json:"notificationTime" binding:"required"` 
 	Timezone         string `json:"timezone" binding:"required"`         
+	Locale           string `json:"locale,omitempty"` // defaults to the request's Accept-Language
 }
 type UserProfileUpdateRequest struct {
 	Username          *string            `json:"username,omitempty"`
 	NotificationTime  *string            `json:"notificationTime,omitempty"`
 	Timezone          *string            `json:"timezone,omitempty"`
 	PlatformUsernames map[string]string `json:"platformUsernames,omitempty"`
-	FCMToken          *string            `json:"fcmToken,omitempty"` 
+	FCMToken          *string            `json:"fcmToken,omitempty"`
+	DevicePlatform    *string            `json:"devicePlatform,omitempty"`
+	AppVersion        *string            `json:"appVersion,omitempty"`
+	NotificationChannels *[]string       `json:"notificationChannels,omitempty"`
+	TelegramChatID    *string            `json:"telegramChatId,omitempty"`
+	WebhookURL        *string            `json:"webhookUrl,omitempty"`
+	Locale            *string            `json:"locale,omitempty"`
+}
+
+// UserUpdate names the fields a targeted user update writes; nil fields keep
+// their stored value, so concurrent schedule claims and device prunes are not
+// overwritten. Empty strings and zero times clear the field.
+type UserUpdate struct {
+	Username                *string
+	NotificationTime        *string
+	Timezone                *string
+	NextReminderAt          *time.Time
+	NextEscalationAt        *time.Time
+	NextWeeklyDigestAt      *time.Time
+	NextMonthlyDigestAt     *time.Time
+	NotificationChannels    *[]string
+	TelegramChatID          *string
+	WebhookURL              *string
+	Locale                  *string
+	PlatformUsernames       map[string]string
+	NotificationPreferences *NotificationPreferences
 }
 type FCMNotification struct {
 	To           string            `json:"to"`                 
@@ -0,0 +1,538 @@
+// Package config loads the service's settings from an optional .env file,
+// environment variables and command-line flags, in increasing order of
+// precedence, and validates all of them before anything starts.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"consistent_1/Infrastructure/logging"
+	"consistent_1/Infrastructure/notifications"
+	"consistent_1/Infrastructure/platform_api"
+	"consistent_1/Infrastructure/tracing"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Modes select where the service keeps its data and reaches the outside
+// world. ModeDev runs with no external services: data lives in memory,
+// platform activity comes from a fixtures file and notifications are only
+// logged.
+const (
+	ModeProd = "prod"
+	ModeDev  = "dev"
+)
+
+// Commands run instead of the service when named after the flags, e.g.
+// "consistify --config prod.env migrate status".
+const (
+	CommandMigrate       = "migrate"        // apply pending schema migrations
+	CommandMigrateStatus = "migrate status" // list schema migrations and exit
+)
+
+// devJWTSecret signs tokens in dev mode when JWT_SECRET is not set.
+const devJWTSecret = "consistify-dev-secret"
+
+// Config is the validated configuration of one process.
+type Config struct {
+	Mode          string
+	Command       string // empty to run the service
+	Server        ServerConfig
+	LogLevel      slog.Level
+	Tracing       tracing.Config
+	Mongo         MongoConfig
+	JWTSecret     string
+	AdminAPIToken string
+	Firebase      FirebaseConfig
+	LeetCode      PlatformConfig
+	Codeforces    PlatformConfig
+	Notifications NotificationConfig
+	Consistency   ConsistencyConfig
+	Scheduler     SchedulerConfig
+	// PlatformFixtures is the fixtures file serving platform activity in dev mode.
+	PlatformFixtures string
+}
+
+func (c *Config) Dev() bool {
+	return c.Mode == ModeDev
+}
+
+type ServerConfig struct {
+	Addr string
+	// RunAPI and RunWorker come from PROCESS_ROLE: "api" serves HTTP only,
+	// "worker" runs scheduled jobs only, and "all" does both.
+	RunAPI            bool
+	RunWorker         bool
+	CORSOrigins       []string
+	ReadHeaderTimeout time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+type MongoConfig struct {
+	URI            string
+	Database       string
+	ConnectTimeout time.Duration
+	// MigrateOnStartup applies pending schema migrations before serving;
+	// without it the service refuses to start until "migrate" has run.
+	MigrateOnStartup bool
+}
+
+// FirebaseConfig is optional; without a project ID push notifications are
+// skipped.
+type FirebaseConfig struct {
+	ProjectID          string
+	ServiceAccountPath string
+	Endpoint           string // e.g. a local stub server
+}
+
+func (c FirebaseConfig) Enabled() bool {
+	return c.ProjectID != ""
+}
+
+// PlatformConfig describes one coding platform. A disabled platform is
+// ignored by consistency checks and rejected as a new handle.
+type PlatformConfig struct {
+	Enabled bool
+	BaseURL string
+	Guard   platform_api.GuardConfig
+}
+
+// NotificationConfig holds the optional channels' transports. Email and
+// Telegram are enabled when Email.Host and TelegramBotToken are set.
+type NotificationConfig struct {
+	WebhookSigningSecret string
+	WebhookAllowPrivate  bool
+	Email                notifications.EmailConfig
+	TelegramBotToken     string
+	TelegramAPIBaseURL   string
+}
+
+type ConsistencyConfig struct {
+	// EscalationOffsets are durations before a streak day ends at UTC
+	// midnight; empty disables streak-at-risk follow-ups.
+	EscalationOffsets []time.Duration
+	CheckConcurrency  int
+	CheckUserTimeout  time.Duration // 0 disables it
+	DigestTime        string
+}
+
+// SchedulerConfig holds the worker's cron specs (standard five fields or
+// descriptors such as "@every 30s") and its lease and pool sizes.
+type SchedulerConfig struct {
+	LeaseTTL        time.Duration
+	JobWorkers      int
+	DailyCheckSpec  string
+	RemindersSpec   string
+	EscalationsSpec string
+	DigestsSpec     string
+	OutboxSpec      string
+	SyncRetriesSpec string
+	JobPollSpec     string
+}
+
+// setting is one configuration key. Each can be set in the config file, as an
+// environment variable of the same name, or as a flag named after it in
+// lower case with dashes, e.g. --mongo-database.
+type setting struct {
+	key   string
+	value string
+	usage string
+}
+
+var settings = []setting{
+	{"MODE", ModeProd, "prod, or dev to run without MongoDB, Firebase or the coding platforms"},
+	{"PLATFORM_FIXTURES", "dev/platform_fixtures.json", "platform activity served in dev mode"},
+	{"SERVER_PORT", ":8080", "address the HTTP server listens on"},
+	{"PROCESS_ROLE", "all", "all, api or worker"},
+	{"CORS_ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:8080", "comma-separated origins allowed to call the API from a browser"},
+	{"SERVER_READ_HEADER_TIMEOUT", "10s", "time allowed to read request headers"},
+	{"SHUTDOWN_TIMEOUT", "25s", "time allowed for requests and jobs to finish on shutdown"},
+	{"LOG_LEVEL", "info", "debug, info, warn or error"},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "", "OTLP/HTTP collector URL, e.g. http://localhost:4318; empty disables trace export"},
+	{"OTEL_TRACES_SAMPLE_RATIO", "1", "share of new traces recorded, 0 to 1"},
+	{"MONGO_URI", "", "MongoDB connection string (required outside dev mode)"},
+	{"MONGO_DATABASE", "consistify_db", "MongoDB database name"},
+	{"MONGO_CONNECT_TIMEOUT", "30s", "time allowed to connect to MongoDB at startup"},
+	{"MONGO_MIGRATE_ON_STARTUP", "true", "apply pending schema migrations at startup; if false, start only once they are applied"},
+	{"JWT_SECRET", "", "secret that signs access tokens (required outside dev mode)"},
+	{"ADMIN_API_TOKEN", "", "X-Admin-Token value for /api/v1/admin and /status; empty disables them"},
+	{"FIREBASE_PROJECT_ID", "", "Firebase project for push notifications; empty disables push"},
+	{"FIREBASE_SERVICE_ACCOUNT_PATH", "", "Firebase service account JSON file, required with FIREBASE_PROJECT_ID"},
+	{"FCM_ENDPOINT", "", "FCM endpoint override, e.g. a local stub server"},
+	{"LEETCODE_ENABLED", "true", "check LeetCode activity"},
+	{"LEETCODE_API_BASE_URL", "https://leetcode.com", "LeetCode base URL"},
+	{"LEETCODE_REQUESTS_PER_SECOND", "2", "LeetCode request rate limit"},
+	{"LEETCODE_MAX_RETRIES", "3", "LeetCode retries after a failed attempt"},
+	{"LEETCODE_BREAKER_THRESHOLD", "5", "consecutive LeetCode failures that open the circuit breaker"},
+	{"LEETCODE_BREAKER_OPEN_TIMEOUT", "1m", "how long the LeetCode breaker stays open"},
+	{"LEETCODE_ATTEMPT_TIMEOUT", "10s", "timeout of a single LeetCode request"},
+	{"CODEFORCES_ENABLED", "true", "check Codeforces activity"},
+	{"CODEFORCES_API_BASE_URL", "https://codeforces.com", "Codeforces base URL"},
+	{"CODEFORCES_REQUESTS_PER_SECOND", "0.5", "Codeforces request rate limit"},
+	{"CODEFORCES_MAX_RETRIES", "3", "Codeforces retries after a failed attempt"},
+	{"CODEFORCES_BREAKER_THRESHOLD", "5", "consecutive Codeforces failures that open the circuit breaker"},
+	{"CODEFORCES_BREAKER_OPEN_TIMEOUT", "1m", "how long the Codeforces breaker stays open"},
+	{"CODEFORCES_ATTEMPT_TIMEOUT", "10s", "timeout of a single Codeforces request"},
+	{"WEBHOOK_SIGNING_SECRET", "", "HMAC secret for outgoing webhook signatures"},
+	{"WEBHOOK_ALLOW_PRIVATE", "false", "let webhooks reach loopback and private addresses; for local stub servers only"},
+	{"SMTP_HOST", "", "SMTP server for email notifications; empty disables email"},
+	{"SMTP_PORT", "587", "SMTP port"},
+	{"SMTP_USERNAME", "", "SMTP user"},
+	{"SMTP_PASSWORD", "", "SMTP password"},
+	{"SMTP_FROM", "", "sender address, required with SMTP_HOST"},
+	{"TELEGRAM_BOT_TOKEN", "", "Telegram bot token; empty disables Telegram"},
+	{"TELEGRAM_API_BASE_URL", "", "Telegram Bot API base URL override"},
+	{"STREAK_ESCALATION_OFFSETS", "3h,1h,15m", "streak-at-risk follow-ups before the streak day ends at UTC midnight, or off"},
+	{"CONSISTENCY_CHECK_CONCURRENCY", "8", "users checked in parallel by the nightly check"},
+	{"CONSISTENCY_CHECK_USER_TIMEOUT", "45s", "time allowed per user in the nightly check; 0 disables it"},
+	{"DIGEST_TIME", "19:00", "local time progress digests are sent, HH:MM"},
+	{"SCHEDULER_LEASE_TTL", "30s", "how long the scheduler lease lasts without renewal"},
+	{"JOB_WORKERS", "2", "concurrent job workers per worker process"},
+	{"DAILY_CHECK_SCHEDULE", "5 0 * * *", "cron spec of the nightly consistency check (server time)"},
+	{"REMINDER_SCHEDULE", "* * * * *", "cron spec of the reminder dispatch"},
+	{"ESCALATION_SCHEDULE", "* * * * *", "cron spec of the streak-at-risk dispatch"},
+	{"DIGEST_SCHEDULE", "* * * * *", "cron spec of the progress digest dispatch"},
+	{"OUTBOX_SCHEDULE", "@every 30s", "cron spec of the notification outbox worker"},
+	{"SYNC_RETRY_SCHEDULE", "@every 5m", "cron spec of the unsynced day re-checks"},
+	{"JOB_POLL_SCHEDULE", "@every 5s", "cron spec on which job workers poll the queue"},
+}
+
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
+
+// Load reads the configuration for a process started with args (without the
+// program name). The config file defaults to .env and may be missing unless
+// named with --config. All invalid settings are reported together.
+func Load(args []string) (*Config, error) {
+	v := viper.New()
+	flags := pflag.NewFlagSet("consistify", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: consistify [flags] [%s | %s]\n\nFlags:\n", CommandMigrate, CommandMigrateStatus)
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", ".env", "settings file in .env format")
+	for _, s := range settings {
+		v.SetDefault(s.key, s.value)
+		flags.String(flagName(s.key), s.value, s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if err := v.BindPFlag(s.key, flags.Lookup(flagName(s.key))); err != nil {
+			return nil, err
+		}
+	}
+	v.AutomaticEnv()
+
+	v.SetConfigFile(*configFile)
+	v.SetConfigType("env")
+	if err := v.ReadInConfig(); err != nil {
+		if !errors.Is(err, os.ErrNotExist) || flags.Changed("config") {
+			return nil, fmt.Errorf("reading %s: %w", *configFile, err)
+		}
+		slog.Info("No config file found, using environment and flags only.", "file", *configFile)
+	}
+
+	l := &loader{v: v, command: strings.Join(flags.Args(), " ")}
+	config := l.load()
+	if len(l.problems) > 0 {
+		return nil, errors.Join(l.problems...)
+	}
+	return config, nil
+}
+
+// loader converts settings and collects every problem instead of stopping
+// at the first.
+type loader struct {
+	v        *viper.Viper
+	command  string
+	problems []error
+}
+
+func (l *loader) fail(key, format string, args ...interface{}) {
+	l.problems = append(l.problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) str(key string) string {
+	return strings.TrimSpace(l.v.GetString(key))
+}
+
+func (l *loader) required(key string) string {
+	value := l.str(key)
+	if value == "" {
+		l.fail(key, "is required")
+	}
+	return value
+}
+
+func (l *loader) list(key string) []string {
+	var values []string
+	for _, part := range strings.Split(l.str(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func (l *loader) boolean(key string) bool {
+	switch strings.ToLower(l.str(key)) {
+	case "true", "1", "yes", "on":
+		return true
+	case "false", "0", "no", "off":
+		return false
+	}
+	l.fail(key, "expected true or false, got %q", l.str(key))
+	return false
+}
+
+func (l *loader) integer(key string, min int) int {
+	n, err := strconv.Atoi(l.str(key))
+	if err != nil {
+		l.fail(key, "expected an integer, got %q", l.str(key))
+		return 0
+	}
+	if n < min {
+		l.fail(key, "must be at least %d, got %d", min, n)
+	}
+	return n
+}
+
+func (l *loader) number(key string, min, max float64) float64 {
+	f, err := strconv.ParseFloat(l.str(key), 64)
+	if err != nil {
+		l.fail(key, "expected a number, got %q", l.str(key))
+		return 0
+	}
+	if f < min || f > max {
+		l.fail(key, "must be between %v and %v, got %v", min, max, f)
+	}
+	return f
+}
+
+// duration accepts Go durations such as 30s or 1h30m; zero is only allowed
+// when allowZero is set.
+func (l *loader) duration(key string, allowZero bool) time.Duration {
+	raw := l.str(key)
+	if raw == "0" && allowZero {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		l.fail(key, "expected a duration such as 30s, got %q", raw)
+		return 0
+	}
+	if d < 0 || (d == 0 && !allowZero) {
+		l.fail(key, "must be positive, got %s", d)
+	}
+	return d
+}
+
+func (l *loader) httpURL(key string) string {
+	raw := l.str(key)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		l.fail(key, "expected an absolute http or https URL, got %q", raw)
+	}
+	return strings.TrimRight(raw, "/")
+}
+
+func (l *loader) cronSpec(key string) string {
+	spec := l.str(key)
+	if _, err := cron.ParseStandard(spec); err != nil {
+		l.fail(key, "invalid cron spec %q: %v", spec, err)
+	}
+	return spec
+}
+
+func (l *loader) load() *Config {
+	config := &Config{
+		Mode:          strings.ToLower(l.str("MODE")),
+		Command:       l.command,
+		AdminAPIToken: l.str("ADMIN_API_TOKEN"),
+	}
+	switch config.Command {
+	case "":
+	case CommandMigrate, CommandMigrateStatus:
+		if config.Mode == ModeDev {
+			l.fail("MODE", "%q needs MongoDB, which dev mode does not use", config.Command)
+		}
+	default:
+		l.problems = append(l.problems, fmt.Errorf("unknown command %q; expected %q or %q", config.Command, CommandMigrate, CommandMigrateStatus))
+	}
+	switch config.Mode {
+	case ModeProd:
+		// Commands only touch the database.
+		if config.Command == "" {
+			config.JWTSecret = l.required("JWT_SECRET")
+		}
+	case ModeDev:
+		config.JWTSecret = l.str("JWT_SECRET")
+		if config.JWTSecret == "" {
+			config.JWTSecret = devJWTSecret
+		}
+		config.PlatformFixtures = l.required("PLATFORM_FIXTURES")
+		if config.PlatformFixtures != "" {
+			if _, err := os.Stat(config.PlatformFixtures); err != nil {
+				l.fail("PLATFORM_FIXTURES", "%v", err)
+			}
+		}
+	default:
+		l.fail("MODE", "expected prod or dev, got %q", config.Mode)
+	}
+
+	config.Server = ServerConfig{
+		Addr:              l.required("SERVER_PORT"),
+		ReadHeaderTimeout: l.duration("SERVER_READ_HEADER_TIMEOUT", false),
+		ShutdownTimeout:   l.duration("SHUTDOWN_TIMEOUT", false),
+	}
+	switch role := strings.ToLower(l.str("PROCESS_ROLE")); role {
+	case "all":
+		config.Server.RunAPI, config.Server.RunWorker = true, true
+	case "api":
+		config.Server.RunAPI = true
+	case "worker":
+		config.Server.RunWorker = true
+	default:
+		l.fail("PROCESS_ROLE", "expected all, api or worker, got %q", role)
+	}
+	for _, origin := range l.list("CORS_ALLOWED_ORIGINS") {
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			l.fail("CORS_ALLOWED_ORIGINS", "origin %q must start with http:// or https://", origin)
+		}
+		config.Server.CORSOrigins = append(config.Server.CORSOrigins, origin)
+	}
+
+	level, err := logging.ParseLevel(l.str("LOG_LEVEL"))
+	if err != nil {
+		l.fail("LOG_LEVEL", "%v", err)
+	}
+	config.LogLevel = level
+	config.Tracing = tracing.Config{
+		Endpoint:    l.str("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		SampleRatio: l.number("OTEL_TRACES_SAMPLE_RATIO", 0, 1),
+	}
+	if config.Tracing.Endpoint != "" {
+		config.Tracing.Endpoint = l.httpURL("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+
+	// Dev mode uses neither MongoDB nor Firebase, so their settings are not read.
+	if !config.Dev() {
+		config.Mongo = MongoConfig{
+			URI:              l.required("MONGO_URI"),
+			Database:         l.required("MONGO_DATABASE"),
+			ConnectTimeout:   l.duration("MONGO_CONNECT_TIMEOUT", false),
+			MigrateOnStartup: l.boolean("MONGO_MIGRATE_ON_STARTUP"),
+		}
+		config.Firebase = FirebaseConfig{
+			ProjectID:          l.str("FIREBASE_PROJECT_ID"),
+			ServiceAccountPath: l.str("FIREBASE_SERVICE_ACCOUNT_PATH"),
+			Endpoint:           l.str("FCM_ENDPOINT"),
+		}
+	}
+	if config.Firebase.Enabled() {
+		if config.Firebase.ServiceAccountPath == "" {
+			l.fail("FIREBASE_SERVICE_ACCOUNT_PATH", "is required when FIREBASE_PROJECT_ID is set")
+		} else if _, err := os.Stat(config.Firebase.ServiceAccountPath); err != nil {
+			l.fail("FIREBASE_SERVICE_ACCOUNT_PATH", "%v", err)
+		}
+	}
+
+	config.LeetCode = l.platform("LEETCODE")
+	config.Codeforces = l.platform("CODEFORCES")
+
+	config.Notifications = NotificationConfig{
+		WebhookSigningSecret: l.str("WEBHOOK_SIGNING_SECRET"),
+		WebhookAllowPrivate:  l.boolean("WEBHOOK_ALLOW_PRIVATE"),
+		TelegramBotToken:     l.str("TELEGRAM_BOT_TOKEN"),
+		TelegramAPIBaseURL:   l.str("TELEGRAM_API_BASE_URL"),
+	}
+	if host := l.str("SMTP_HOST"); host != "" {
+		config.Notifications.Email = notifications.EmailConfig{
+			Host:     host,
+			Port:     l.integer("SMTP_PORT", 1),
+			Username: l.str("SMTP_USERNAME"),
+			Password: l.v.GetString("SMTP_PASSWORD"),
+			From:     l.required("SMTP_FROM"),
+		}
+	}
+	if config.Notifications.TelegramAPIBaseURL != "" {
+		config.Notifications.TelegramAPIBaseURL = l.httpURL("TELEGRAM_API_BASE_URL")
+	}
+
+	config.Consistency = ConsistencyConfig{
+		EscalationOffsets: l.escalationOffsets("STREAK_ESCALATION_OFFSETS"),
+		CheckConcurrency:  l.integer("CONSISTENCY_CHECK_CONCURRENCY", 1),
+		CheckUserTimeout:  l.duration("CONSISTENCY_CHECK_USER_TIMEOUT", true),
+		DigestTime:        l.str("DIGEST_TIME"),
+	}
+	if _, err := time.Parse("15:04", config.Consistency.DigestTime); err != nil {
+		l.fail("DIGEST_TIME", "expected HH:MM, got %q", config.Consistency.DigestTime)
+	}
+
+	config.Scheduler = SchedulerConfig{
+		LeaseTTL:        l.duration("SCHEDULER_LEASE_TTL", false),
+		JobWorkers:      l.integer("JOB_WORKERS", 1),
+		DailyCheckSpec:  l.cronSpec("DAILY_CHECK_SCHEDULE"),
+		RemindersSpec:   l.cronSpec("REMINDER_SCHEDULE"),
+		EscalationsSpec: l.cronSpec("ESCALATION_SCHEDULE"),
+		DigestsSpec:     l.cronSpec("DIGEST_SCHEDULE"),
+		OutboxSpec:      l.cronSpec("OUTBOX_SCHEDULE"),
+		SyncRetriesSpec: l.cronSpec("SYNC_RETRY_SCHEDULE"),
+		JobPollSpec:     l.cronSpec("JOB_POLL_SCHEDULE"),
+	}
+	return config
+}
+
+// platform reads <PREFIX>_ENABLED, _API_BASE_URL and the guard settings
+// _REQUESTS_PER_SECOND, _MAX_RETRIES, _BREAKER_THRESHOLD,
+// _BREAKER_OPEN_TIMEOUT and _ATTEMPT_TIMEOUT. A disabled platform's other
+// settings are not checked.
+func (l *loader) platform(prefix string) PlatformConfig {
+	if !l.boolean(prefix + "_ENABLED") {
+		return PlatformConfig{}
+	}
+	guard := platform_api.DefaultGuardConfig(l.number(prefix+"_REQUESTS_PER_SECOND", 0.01, 1000))
+	guard.MaxRetries = l.integer(prefix+"_MAX_RETRIES", 0)
+	guard.FailureThreshold = l.integer(prefix+"_BREAKER_THRESHOLD", 1)
+	guard.OpenTimeout = l.duration(prefix+"_BREAKER_OPEN_TIMEOUT", false)
+	guard.AttemptTimeout = l.duration(prefix+"_ATTEMPT_TIMEOUT", false)
+	return PlatformConfig{
+		Enabled: true,
+		BaseURL: l.httpURL(prefix + "_API_BASE_URL"),
+		Guard:   guard,
+	}
+}
+
+// escalationOffsets reads a comma-separated list of durations before the end
+// of a streak day, e.g. "3h,1h,15m"; "off" disables streak-at-risk follow-ups.
+func (l *loader) escalationOffsets(key string) []time.Duration {
+	if strings.EqualFold(l.str(key), "off") {
+		return nil
+	}
+	var offsets []time.Duration
+	for _, part := range l.list(key) {
+		offset, err := time.ParseDuration(part)
+		if err != nil {
+			l.fail(key, "expected durations such as 3h,1h,15m or off, got %q", part)
+			continue
+		}
+		if offset <= 0 || offset >= 24*time.Hour {
+			l.fail(key, "offset %s must be between 0 and 24h", offset)
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
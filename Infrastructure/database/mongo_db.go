@@ -2,28 +2,34 @@ package database
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"time"
 
-	"github.com/spf13/viper"
+	"consistent_1/Infrastructure/metrics"
+
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel/trace"
 )
 type MongoClient struct {
 	Client *mongo.Client
 	DB     *mongo.Database
 }
-func NewMongoClient() (*MongoClient, error) {
-	mongoURI := viper.GetString("MONGO_URI")
-	if mongoURI == "" {
-		log.Fatal("MONGO_URI not set in environment variables")
+// NewMongoClient connects to uri and checks the primary within
+// connectTimeout. Repositories use the database named database.
+func NewMongoClient(uri, database string, connectTimeout time.Duration) (*MongoClient, error) {
+	if uri == "" {
+		return nil, errors.New("mongo URI is empty")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetMonitor(chainMonitors(metrics.MongoMonitor(), childSpansOnly(otelmongo.NewMonitor()))))
 	if err != nil {
 		return nil, err
 	}
@@ -32,19 +38,63 @@ func NewMongoClient() (*MongoClient, error) {
 		return nil, err
 	}
 
-	log.Println("Successfully connected to MongoDB!")
+	slog.Info("Successfully connected to MongoDB!")
 
 	return &MongoClient{
 		Client: client,
-		DB:     client.Database("consistify_db"), 
+		DB:     client.Database(database),
 	}, nil
 }
 
+// Ping checks that the primary is reachable.
+func (mc *MongoClient) Ping(ctx context.Context) error {
+	return mc.Client.Ping(ctx, readpref.Primary())
+}
 
 func (mc *MongoClient) Disconnect(ctx context.Context) error {
 	if mc.Client == nil {
 		return nil
 	}
-	log.Println("Disconnecting from MongoDB...")
+	slog.InfoContext(ctx, "Disconnecting from MongoDB...")
 	return mc.Client.Disconnect(ctx)
 }
+
+// chainMonitors fans driver events out to several monitors, since a client
+// takes only one.
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				if m.Started != nil {
+					m.Started(ctx, e)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				if m.Succeeded != nil {
+					m.Succeeded(ctx, e)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				if m.Failed != nil {
+					m.Failed(ctx, e)
+				}
+			}
+		},
+	}
+}
+
+// childSpansOnly traces only commands issued under an existing span. Without
+// it, job queue polling and lease renewals would each start a trace.
+func childSpansOnly(monitor *event.CommandMonitor) *event.CommandMonitor {
+	started := monitor.Started
+	monitor.Started = func(ctx context.Context, e *event.CommandStartedEvent) {
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			started(ctx, e)
+		}
+	}
+	return monitor
+}
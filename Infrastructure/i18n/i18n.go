@@ -0,0 +1,196 @@
+// Package i18n renders user-facing text from per-locale message catalogs.
+//
+// Each file in locales/ maps message keys to text/template strings and is
+// named after its BCP 47 tag. Lookups fall back to DefaultLocale, then to the
+// key itself, so a missing translation never produces an empty message.
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"consistent_1/Domain"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when a user or request has no supported preference.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var funcs = template.FuncMap{
+	// plural picks the singular or plural form for n; locales without
+	// grammatical number simply repeat the same word.
+	"plural": func(n int, one, other string) string {
+		if n == 1 || n == -1 {
+			return one
+		}
+		return other
+	},
+}
+
+var (
+	catalog = map[string]map[string]*template.Template{}
+	locales []string
+	matcher language.Matcher
+)
+
+func init() {
+	if err := load(); err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+}
+
+func load() error {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		locale := strings.TrimSuffix(entry.Name(), ".json")
+		raw, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			return fmt.Errorf("locale %s: %w", locale, err)
+		}
+		templates := make(map[string]*template.Template, len(messages))
+		for key, text := range messages {
+			tmpl, err := template.New(key).Funcs(funcs).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return fmt.Errorf("locale %s: %w", locale, err)
+			}
+			templates[key] = tmpl
+		}
+		catalog[locale] = templates
+		locales = append(locales, locale)
+	}
+	if _, ok := catalog[DefaultLocale]; !ok {
+		return fmt.Errorf("default locale %q has no message file", DefaultLocale)
+	}
+
+	// The matcher falls back to its first tag, so the default goes first.
+	sort.Slice(locales, func(i, j int) bool {
+		if locales[i] == DefaultLocale || locales[j] == DefaultLocale {
+			return locales[i] == DefaultLocale
+		}
+		return locales[i] < locales[j]
+	})
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.Make(locale)
+	}
+	matcher = language.NewMatcher(tags)
+	return nil
+}
+
+// Locales lists the shipped locales, DefaultLocale first.
+func Locales() []string {
+	return append([]string(nil), locales...)
+}
+
+// Match maps a locale such as "am-ET" onto a shipped locale.
+func Match(locale string) (string, bool) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(tag)
+	if confidence < language.High {
+		return "", false
+	}
+	return locales[index], true
+}
+
+// Negotiate picks the best shipped locale for an Accept-Language header.
+func Negotiate(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return locales[index]
+}
+
+// T renders the message for key in locale with data.
+func T(locale, key string, data interface{}) string {
+	for _, candidate := range []string{locale, DefaultLocale} {
+		tmpl, ok := catalog[candidate][key]
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			slog.Error("i18n: rendering message", "locale", candidate, "key", key, "error", err)
+			continue
+		}
+		return buf.String()
+	}
+	return key
+}
+
+// errorKeys maps domain errors that reach API clients onto message keys.
+var errorKeys = []struct {
+	err error
+	key string
+}{
+	{domain.ErrUserNotFound, "error.user_not_found"},
+	{domain.ErrInvalidCredentials, "error.invalid_credentials"},
+	{domain.ErrEmailAlreadyExists, "error.email_already_exists"},
+	{domain.ErrPasswordsDoNotMatch, "error.passwords_do_not_match"},
+	{domain.ErrInvalidToken, "error.invalid_token"},
+	{domain.ErrUnauthorized, "error.unauthorized"},
+	{domain.ErrForbidden, "error.forbidden"},
+	{domain.ErrConsistencyNotFound, "error.consistency_not_found"},
+	{domain.ErrPlatformNotLinked, "error.platform_not_linked"},
+	{domain.ErrExternalAPIFailed, "error.external_api_failed"},
+	{domain.ErrProcessingConsistency, "error.processing_consistency"},
+	{domain.ErrInvalidNotificationTime, "error.invalid_notification_time"},
+	{domain.ErrUnsupportedPlatform, "error.unsupported_platform"},
+	{domain.ErrPlatformUserNotFound, "error.platform_user_not_found"},
+	{domain.ErrPlatformDisabled, "error.platform_disabled"},
+	{domain.ErrDeviceNotFound, "error.device_not_found"},
+	{domain.ErrUnsupportedChannel, "error.unsupported_channel"},
+	{domain.ErrInvalidWebhookURL, "error.invalid_webhook_url"},
+	{domain.ErrUnsupportedNotificationKind, "error.unsupported_notification_kind"},
+	{domain.ErrInvalidQuietHours, "error.invalid_quiet_hours"},
+	{domain.ErrInvalidReportPeriod, "error.invalid_report_period"},
+	{domain.ErrInboxItemNotFound, "error.inbox_item_not_found"},
+	{domain.ErrUnsupportedLocale, "error.unsupported_locale"},
+	{domain.ErrJobNotFound, "error.job_not_found"},
+	{domain.ErrJobNotRequeueable, "error.job_not_requeueable"},
+}
+
+// Error renders err in locale. Errors without a catalog entry, such as request
+// binding failures, are returned verbatim.
+func Error(locale string, err error) string {
+	var handleErr *domain.PlatformHandleError
+	if errors.As(err, &handleErr) {
+		return T(locale, "error.platform_handle", map[string]string{
+			"Platform": handleErr.Platform,
+			"Username": handleErr.Username,
+			"Reason":   Error(locale, handleErr.Err),
+		})
+	}
+	for _, entry := range errorKeys {
+		if errors.Is(err, entry.err) {
+			return T(locale, entry.key, nil)
+		}
+	}
+	return err.Error()
+}
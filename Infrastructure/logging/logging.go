@@ -0,0 +1,100 @@
+// Package logging configures log/slog for the service: JSON output, attributes
+// carried on the context (request and run IDs), and redaction of personal data.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var level = new(slog.LevelVar)
+
+// Setup installs the JSON logger as the slog default. The standard log
+// package is routed through it too, so stray log.Printf calls stay structured.
+func Setup() {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+}
+
+// ParseLevel accepts debug, info, warn or error in any case.
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return l, fmt.Errorf("unknown log level %q", name)
+	}
+	return l, nil
+}
+
+// SetLevel changes the minimum level of the default logger.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Fatal logs msg at error level and exits.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// NewID returns a random 16-character hex ID for requests and runs.
+func NewID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type attrsKey struct{}
+
+// With returns a context whose log records carry attrs in addition to any
+// attributes already on ctx.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	combined := make([]slog.Attr, 0, len(existing)+len(attrs))
+	combined = append(combined, existing...)
+	combined = append(combined, attrs...)
+	return context.WithValue(ctx, attrsKey{}, combined)
+}
+
+// WithRequestID tags log records made under ctx with an HTTP request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(ctx, slog.String("request_id", id))
+}
+
+// WithRunID tags log records made under ctx with a scheduler run ID.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return With(ctx, slog.String("run_id", id))
+}
+
+// contextHandler adds the attributes stored on the record's context and the
+// IDs of the span it was logged under, so log lines can be found from a trace.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// FCM registration tokens look like "<instance id>:<long base64url blob>".
+	fcmTokenPattern = regexp.MustCompile(`[A-Za-z0-9_\-]{11,}:[A-Za-z0-9_\-]{100,}`)
+)
+
+// secretKeys are attribute keys whose values are never logged.
+var secretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"fcm_token":     true,
+	"device_token":  true,
+	"authorization": true,
+}
+
+// Redact masks email addresses and FCM tokens inside s, keeping enough of
+// each to tell values apart: "ab***@example.com", "fcm-token…a1b2".
+func Redact(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, RedactEmail)
+	return fcmTokenPattern.ReplaceAllStringFunc(s, RedactToken)
+}
+
+// RedactEmail keeps the first two characters of the local part and the domain.
+func RedactEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return "***"
+	}
+	local := email[:at]
+	if len(local) > 2 {
+		local = local[:2]
+	}
+	return local + "***" + email[at:]
+}
+
+// RedactToken keeps the last four characters of a token.
+func RedactToken(token string) string {
+	if len(token) <= 8 {
+		return "***"
+	}
+	return "…" + token[len(token)-4:]
+}
+
+// redactAttr is the handler's ReplaceAttr hook. It masks secret keys outright
+// and scrubs emails and tokens from every string and error value, which
+// covers messages and driver errors that echo user data.
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if secretKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, RedactToken(a.Value.String()))
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); strings.Contains(s, "@") || strings.Contains(s, ":") {
+			return slog.String(a.Key, Redact(s))
+		}
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
@@ -0,0 +1,93 @@
+// Package metrics defines the Prometheus collectors exposed at /metrics.
+//
+// Collectors register with the default registry, so Handler also serves the
+// Go runtime and process metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "consistify"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	PlatformRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "platform_api_requests_total",
+		Help:      "Outbound coding platform API attempts by outcome: ok, client_error, throttled, server_error, network_error, or rejected by the open circuit breaker.",
+	}, []string{"platform", "outcome"})
+
+	PlatformRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "platform_api_request_duration_seconds",
+		Help:      "Latency of single outbound coding platform API attempts.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"platform"})
+
+	ConsistencyChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "consistency_checks_total",
+		Help:      "Per-user consistency checks by outcome: consistent, inconsistent, unknown or error.",
+	}, []string{"outcome"})
+
+	ConsistencyCheckDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consistency_check_duration_seconds",
+		Help:      "Duration of per-user consistency checks by outcome.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"outcome"})
+
+	Notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notification deliveries per channel by outcome: sent, skipped, error (one attempt failed) or failed (gave up after the last attempt).",
+	}, []string{"channel", "outcome"})
+
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Background job runs by type and outcome.",
+	}, []string{"type", "outcome"})
+
+	JobRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_run_duration_seconds",
+		Help:      "Duration of background job runs by type.",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 60, 300, 900, 3600},
+	}, []string{"type"})
+
+	MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "MongoDB command latency by command, collection and outcome.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+	}, []string{"command", "collection", "outcome"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Since returns the seconds elapsed since start, for observing histograms.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor times every command the driver sends, so repository latency
+// is measured without instrumenting each repository method.
+func MongoMonitor() *event.CommandMonitor {
+	// The collection is only in the started event; remember it until the
+	// command finishes.
+	var collections sync.Map
+	started := func(_ context.Context, e *event.CommandStartedEvent) {
+		// Most commands name the collection as their value; getMore names it
+		// in a separate field.
+		collection := "none"
+		for _, key := range []string{e.CommandName, "collection"} {
+			if value, err := e.Command.LookupErr(key); err == nil {
+				if name, ok := value.StringValueOK(); ok {
+					collection = name
+					break
+				}
+			}
+		}
+		collections.Store(e.RequestID, collection)
+	}
+	finished := func(e event.CommandFinishedEvent, outcome string) {
+		collection, ok := collections.LoadAndDelete(e.RequestID)
+		if !ok {
+			collection = "none"
+		}
+		MongoCommandDuration.WithLabelValues(e.CommandName, collection.(string), outcome).
+			Observe(e.Duration.Seconds())
+	}
+	return &event.CommandMonitor{
+		Started: started,
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.CommandFinishedEvent, "ok")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.CommandFinishedEvent, "error")
+		},
+	}
+}
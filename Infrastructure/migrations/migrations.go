@@ -0,0 +1,252 @@
+// Package migrations evolves the MongoDB schema in numbered steps: index
+// builds, backfills and data fixes. Each step runs once per database, and the
+// steps applied so far are recorded in the schema_migrations collection.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const collectionName = "schema_migrations"
+
+// leaseTTL bounds how long a crashed process blocks the others; the runner
+// renews the lease every leaseTTL/3 while it works. Processes waiting for the
+// lease try again every leaseRetryWait.
+const (
+	leaseTTL       = 2 * time.Minute
+	leaseRetryWait = 2 * time.Second
+)
+
+// Migration is one schema step. Up must be safe to run again after it failed
+// part way, since a step is only recorded once it has completed.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// record is an applied migration as stored in schema_migrations.
+type record struct {
+	Version     int           `bson:"_id"`
+	Description string        `bson:"description"`
+	AppliedAt   time.Time     `bson:"appliedAt"`
+	Duration    time.Duration `bson:"duration"`
+}
+
+// Status is a known migration and when it was applied, if it has been.
+type Status struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+type Migrator struct {
+	db         *mongo.Database
+	leases     repositories.LeaseRepository
+	migrations []Migration
+	holder     string
+}
+
+// NewMigrator runs this build's migrations against db.
+func NewMigrator(db *mongo.Database, leases repositories.LeaseRepository) *Migrator {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &Migrator{
+		db:         db,
+		leases:     leases,
+		migrations: all,
+		holder:     fmt.Sprintf("%s:%d:migrate", host, os.Getpid()),
+	}
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]record, error) {
+	cursor, err := m.db.Collection(collectionName).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Status lists every migration this build knows, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Version: migration.Version, Description: migration.Description}
+		if r, ok := applied[migration.Version]; ok {
+			appliedAt := r.AppliedAt
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations not yet applied, oldest first.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order and returns how many it applied.
+// It holds the migrations lease throughout, waiting for any other process that
+// is already migrating, and stops at the first step that fails.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "Migrator.Up")
+	defer span.End()
+
+	ctx, release, err := m.lock(ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return 0, err
+	}
+	defer release()
+
+	// Read after locking: whoever held the lease may have applied some.
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		tracing.Fail(span, err)
+		return 0, fmt.Errorf("reading applied migrations: %w", err)
+	}
+	for i, migration := range pending {
+		if err := m.apply(ctx, migration); err != nil {
+			tracing.Fail(span, err)
+			return i, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+	}
+	span.SetAttributes(attribute.Int("migrations.applied", len(pending)))
+	return len(pending), nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	ctx, span := tracing.Start(ctx, "migration",
+		attribute.Int("migration.version", migration.Version),
+		attribute.String("migration.description", migration.Description))
+	defer span.End()
+
+	slog.InfoContext(ctx, "Applying migration", "version", migration.Version, "description", migration.Description)
+	start := time.Now()
+	if err := migration.Up(ctx, m.db); err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	_, err := m.db.Collection(collectionName).InsertOne(ctx, record{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   time.Now().UTC(),
+		Duration:    time.Since(start),
+	})
+	if err != nil {
+		tracing.Fail(span, err)
+		return fmt.Errorf("recording migration: %w", err)
+	}
+	slog.InfoContext(ctx, "Applied migration", "version", migration.Version, "duration", time.Since(start).String())
+	return nil
+}
+
+// lock waits for the migrations lease and keeps renewing it until release is
+// called. The returned context ends if the lease is lost.
+func (m *Migrator) lock(ctx context.Context) (context.Context, func(), error) {
+	waiting := false
+	for {
+		_, err := m.leases.Acquire(ctx, domain.MigrationsLeaseName, m.holder, leaseTTL)
+		if err == nil {
+			break
+		}
+		if err != domain.ErrLeaseHeld {
+			return nil, nil, fmt.Errorf("acquiring migrations lease: %w", err)
+		}
+		if !waiting {
+			slog.InfoContext(ctx, "Waiting for another process to finish migrations")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(leaseRetryWait):
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if _, err := m.leases.Acquire(ctx, domain.MigrationsLeaseName, m.holder, leaseTTL); err != nil {
+				slog.ErrorContext(ctx, "Lost migrations lease", "error", err)
+				cancel()
+				return
+			}
+		}
+	}()
+	release := func() {
+		close(done)
+		cancel()
+		releaseCtx, cancelRelease := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelRelease()
+		if err := m.leases.Release(releaseCtx, domain.MigrationsLeaseName, m.holder); err != nil {
+			slog.Warn("Error releasing migrations lease", "error", err)
+		}
+	}
+	return ctx, release, nil
+}
+
+// validate checks that versions are positive and strictly ascending, so a
+// mis-numbered step fails at startup instead of running out of order.
+func validate(migrations []Migration) error {
+	previous := 0
+	for _, migration := range migrations {
+		if migration.Version <= previous {
+			return fmt.Errorf("migration %d (%s) must come after version %d", migration.Version, migration.Description, previous)
+		}
+		previous = migration.Version
+	}
+	return nil
+}
+
+func init() {
+	if err := validate(all); err != nil {
+		panic(err)
+	}
+}
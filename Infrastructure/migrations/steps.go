@@ -0,0 +1,232 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// all is every migration in version order. Append new steps; never renumber
+// or edit a step that has shipped. Steps spell out the indexes they build
+// rather than asking the repositories, so a shipped step keeps building exactly
+// what it did when it shipped; changing an index takes a new step.
+var all = []Migration{
+	{1, "create notification, inbox, achievement and job indexes", createServiceIndexes},
+	{2, "remove duplicate daily consistency records", removeDuplicateDays},
+	{3, "create daily consistency indexes", createConsistencyIndexes},
+	{4, "create user indexes, including unique email", createUserIndexes},
+	{5, "move legacy FCM tokens into devices", backfillDevices},
+	{6, "create unique inbox dedup key index", createInboxDedupIndex},
+}
+
+// createServiceIndexes builds the indexes these collections were created with
+// on every startup before migrations existed.
+func createServiceIndexes(ctx context.Context, db *mongo.Database) error {
+	// Finished jobs and run history are kept for 30 days, long enough to
+	// investigate a failure and short enough that per-minute jobs don't pile up.
+	historyRetention := int32((30 * 24 * time.Hour).Seconds())
+
+	for collection, models := range map[string][]mongo.IndexModel{
+		// The unique dedup key allows at most one notification per key; the
+		// others serve the outbox worker and the history listing.
+		"notifications": {
+			{Keys: bson.D{{Key: "dedupKey", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+		// The paged listing and the unread count.
+		"inbox": {
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "read", Value: 1}}},
+		},
+		// Each achievement unlocks at most once per user.
+		"achievements": {
+			{
+				Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "achievementId", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		// Dedup and worker indexes, and a TTL index expiring succeeded jobs.
+		// Dead jobs are kept until re-enqueued.
+		"jobs": {
+			{
+				Keys: bson.D{{Key: "dedupKey", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"dedupKey": bson.M{"$type": "string"}}),
+			},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "runAt", Value: 1}}},
+			{
+				Keys: bson.D{{Key: "updatedAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(historyRetention).
+					SetPartialFilterExpression(bson.M{"status": domain.JobStatusSucceeded}),
+			},
+		},
+		"job_runs": {
+			{Keys: bson.D{{Key: "jobId", Value: 1}, {Key: "startedAt", Value: -1}}},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "startedAt", Value: -1}}},
+			{
+				Keys:    bson.D{{Key: "startedAt", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(historyRetention),
+			},
+		},
+	} {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("%s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// removeDuplicateDays keeps the most recently updated record of each user and
+// day, left behind by concurrent upserts before the unique index existed.
+func removeDuplicateDays(ctx context.Context, db *mongo.Database) error {
+	days := db.Collection("daily_consistencies")
+	cursor, err := days.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "updatedAt", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"userId": "$userId", "date": "$date"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var removed int64
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs []primitive.ObjectID `bson:"ids"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+		result, err := days.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return err
+		}
+		removed += result.DeletedCount
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Removed duplicate daily consistency records", "removed", removed)
+	return nil
+}
+
+// createConsistencyIndexes builds the unique index that keeps one record per
+// user and day, which also serves history queries, and the index behind
+// GetDaysDueForSync.
+func createConsistencyIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("daily_consistencies").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "date", Value: 1}, {Key: "syncStatus", Value: 1}, {Key: "nextSyncAt", Value: 1}}},
+	})
+	return err
+}
+
+// createUserIndexes builds the unique email index behind GetUserByEmail and
+// the indexes on each schedule field polled every minute by usersDueBy. It
+// refuses to guess which of several accounts sharing an email to keep; those
+// have to be merged by hand before the step can pass.
+func createUserIndexes(ctx context.Context, db *mongo.Database) error {
+	cursor, err := db.Collection("users").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$email", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	var shared []struct {
+		Email string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &shared); err != nil {
+		return err
+	}
+	if len(shared) > 0 {
+		emails := make([]string, len(shared))
+		for i, s := range shared {
+			emails[i] = logging.RedactEmail(s.Email)
+		}
+		return fmt.Errorf("%d emails belong to more than one user (%s); merge or remove those accounts and run migrations again",
+			len(shared), strings.Join(emails, ", "))
+	}
+	_, err = db.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "nextReminderAt", Value: 1}}},
+		{Keys: bson.D{{Key: "nextEscalationAt", Value: 1}}},
+		{Keys: bson.D{{Key: "nextWeeklyDigestAt", Value: 1}}},
+		{Keys: bson.D{{Key: "nextMonthlyDigestAt", Value: 1}}},
+	})
+	return err
+}
+
+// backfillDevices turns the bare tokens users registered before device
+// records existed into devices, so only Devices needs reading from now on.
+func backfillDevices(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{"fcmTokens.0": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now().UTC()
+	var migrated int
+	for cursor.Next(ctx) {
+		var user domain.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		known := make(map[string]bool, len(user.Devices))
+		for _, d := range user.Devices {
+			known[d.Token] = true
+		}
+		var devices []domain.Device
+		for _, token := range user.FCMTokens {
+			if token != "" && !known[token] {
+				known[token] = true
+				devices = append(devices, domain.Device{Token: token, LastSeen: user.UpdatedAt, CreatedAt: now})
+			}
+		}
+		update := bson.M{"$unset": bson.M{"fcmTokens": ""}}
+		if len(devices) > 0 {
+			update["$push"] = bson.M{"devices": bson.M{"$each": devices}}
+		}
+		if _, err := users.UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+			return err
+		}
+		migrated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Moved legacy FCM tokens into devices", "users", migrated)
+	return nil
+}
+
+// createInboxDedupIndex lets each notification reach the inbox at most once,
+// including ones that never entered the outbox.
+func createInboxDedupIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("inbox").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "dedupKey", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"dedupKey": bson.M{"$type": "string"}}),
+	})
+	return err
+}
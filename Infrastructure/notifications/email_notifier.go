@@ -0,0 +1,165 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// EmailConfig points the email notifier at an SMTP relay. Username and
+// Password may be left empty for relays that do not require authentication.
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// emailSendTimeout bounds a whole SMTP exchange, so a hung relay cannot hold an
+// outbox worker.
+const emailSendTimeout = 30 * time.Second
+
+type emailNotifier struct {
+	config EmailConfig
+}
+
+func NewEmailNotifier(config EmailConfig) Notifier {
+	return &emailNotifier{config: config}
+}
+
+func (n *emailNotifier) Channel() string {
+	return domain.ChannelEmail
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	errs := make([]error, len(deliveries))
+	for i, d := range deliveries {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = n.send(ctx, d.User.Email, d.Notification)
+	}
+	return errs
+}
+
+func (n *emailNotifier) send(ctx context.Context, to string, notification Notification) error {
+	if to == "" {
+		return domain.ErrRecipientNotConfigured
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	if notification.HTMLBody == "" {
+		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		msg.WriteString("\r\n")
+		msg.WriteString(notification.Body)
+		msg.WriteString("\r\n")
+	} else {
+		writeAlternativeBody(&msg, notification.Body, notification.HTMLBody)
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+	addr := net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port))
+	if err := sendMail(ctx, addr, n.config.Host, auth, n.config.From, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("SMTP send via %s failed: %w", addr, err)
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail bounded by ctx and emailSendTimeout: the
+// connection is dialed with ctx, carries the deadline, and is closed if ctx
+// is cancelled mid-exchange.
+func sendMail(ctx context.Context, addr, host string, auth smtp.Auth, from, to string, msg []byte) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, emailSendTimeout)
+	defer cancel()
+	defer func() {
+		// Report the timeout rather than the closed connection it caused.
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	}()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server does not support AUTH")
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// writeAlternativeBody writes a multipart/alternative body so clients that
+// cannot render HTML fall back to the plain-text version.
+func writeAlternativeBody(msg *bytes.Buffer, text, html string) {
+	writer := multipart.NewWriter(msg)
+	fmt.Fprintf(msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		w.Write([]byte(part.content))
+	}
+	writer.Close()
+}
@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// smtpStub is a minimal SMTP relay that accepts one message and records the
+// envelope and data it received.
+type smtpStub struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &smtpStub{listener: listener, done: make(chan struct{})}
+	go stub.serve()
+	t.Cleanup(func() { listener.Close() })
+	return stub
+}
+
+func (s *smtpStub) config() EmailConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return EmailConfig{Host: host, Port: p, From: "noreply@consistify.test"}
+}
+
+func (s *smtpStub) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 stub ready")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 stub")
+		case "MAIL":
+			s.from = strings.TrimSuffix(strings.TrimPrefix(line, "MAIL FROM:<"), ">")
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			s.to = append(s.to, strings.TrimSuffix(strings.TrimPrefix(line, "RCPT TO:<"), ">"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 send data")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.data = string(data)
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestEmailNotifierSendsThroughRelay(t *testing.T) {
+	stub := newSMTPStub(t)
+
+	errs := NewEmailNotifier(stub.config()).Notify(context.Background(), []Delivery{{
+		User:         &domain.User{Email: "alice@example.com"},
+		Notification: Notification{Title: "Reminder", Body: "Solve a problem", HTMLBody: "<p>Solve a problem</p>"},
+	}})
+	if errs[0] != nil {
+		t.Fatalf("Notify() error = %v", errs[0])
+	}
+	<-stub.done
+
+	if stub.from != "noreply@consistify.test" {
+		t.Errorf("MAIL FROM = %q", stub.from)
+	}
+	if len(stub.to) != 1 || stub.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %v", stub.to)
+	}
+	for _, want := range []string{"Subject: Reminder", "multipart/alternative", "Solve a problem", "<p>Solve a problem</p>"} {
+		if !strings.Contains(stub.data, want) {
+			t.Errorf("message does not contain %q:\n%s", want, stub.data)
+		}
+	}
+}
+
+func TestEmailNotifierGivesUpOnHungRelay(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		// Accept and never greet, like a relay that has stopped responding.
+		conn, err := listener.Accept()
+		if err == nil {
+			bufio.NewReader(conn).ReadByte()
+			conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	errs := NewEmailNotifier(EmailConfig{Host: host, Port: p, From: "noreply@consistify.test"}).Notify(ctx, []Delivery{{
+		User: &domain.User{Email: "alice@example.com"},
+	}})
+	if errs[0] == nil {
+		t.Fatal("Notify() error = nil, want a timeout")
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("Notify() took %s, want it to stop at the context deadline", elapsed)
+	}
+}
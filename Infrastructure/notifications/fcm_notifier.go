@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+
+	"consistent_1/Domain"
+)
+
+type fcmNotifier struct {
+	fcmService FCMService
+}
+
+// NewFCMNotifier delivers notifications as push messages to every device the
+// user has registered, batching all recipients into as few FCM calls as possible.
+func NewFCMNotifier(fcmService FCMService) Notifier {
+	return &fcmNotifier{fcmService: fcmService}
+}
+
+func (n *fcmNotifier) Channel() string {
+	return domain.ChannelPush
+}
+
+// Notify reports domain.ErrRecipientNotConfigured for users without a live
+// device, whether they never registered one or FCM rejected every token.
+func (n *fcmNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	errs := make([]error, len(deliveries))
+
+	var messages []PushMessage
+	var owners []int
+	for i, d := range deliveries {
+		for _, token := range d.User.DeviceTokens() {
+			messages = append(messages, PushMessage{
+				Token: token,
+				Title: d.Notification.Title,
+				Body:  d.Notification.Body,
+				Data:  d.Notification.Data,
+			})
+			owners = append(owners, i)
+		}
+	}
+
+	reached := make([]bool, len(deliveries))
+	if len(messages) > 0 {
+		for i, result := range n.fcmService.SendBatch(ctx, messages) {
+			// Dead tokens are pruned by the FCM service and are not the recipient's failure.
+			if errors.Is(result.Err, domain.ErrDeviceTokenInvalid) {
+				continue
+			}
+			owner := owners[i]
+			reached[owner] = true
+			if result.Err != nil {
+				errs[owner] = errors.Join(errs[owner], result.Err)
+			}
+		}
+	}
+	for i := range deliveries {
+		if !reached[i] {
+			errs[i] = domain.ErrRecipientNotConfigured
+		}
+	}
+	return errs
+}
@@ -3,29 +3,67 @@ package notifications
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
-	firebase "firebase.google.com/go"          
-	"firebase.google.com/go/messaging" 
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/logging"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
 )
+// MaxBatchSize is the largest number of messages FCM accepts in one SendEach call.
+const MaxBatchSize = 500
+
+// PushMessage is a single notification addressed to one device token.
+type PushMessage struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// SendResult is the delivery outcome for one PushMessage. Err wraps
+// domain.ErrDeviceTokenInvalid when FCM rejected the token itself.
+type SendResult struct {
+	Token     string
+	MessageID string
+	Err       error
+}
+
 type FCMService interface {
 	SendNotification(ctx context.Context, token string, title, body string, data map[string]string) error
+	SendBatch(ctx context.Context, messages []PushMessage) []SendResult
+	// Ready reports whether the messaging client was initialized.
+	Ready() error
+}
+
+// DeviceTokenStore forgets tokens that FCM reports as no longer deliverable.
+type DeviceTokenStore interface {
+	RemoveDeviceTokens(ctx context.Context, tokens []string) error
 }
 
 type fcmService struct {
 	messagingClient *messaging.Client 
+	tokenStore      DeviceTokenStore
 }
-func NewFCMService(app *firebase.App) FCMService {
+func NewFCMService(app *firebase.App, tokenStore DeviceTokenStore) (FCMService, error) {
 	client, err := app.Messaging(context.Background())
 	if err != nil {
-		log.Fatalf("Error getting Firebase Messaging client: %v", err)
+		return nil, fmt.Errorf("getting Firebase Messaging client: %w", err)
 	}
-	log.Println("Firebase Messaging client initialized successfully.")
+	slog.Info("Firebase Messaging client initialized successfully.")
 	return &fcmService{
 		messagingClient: client,
-	}
+		tokenStore:      tokenStore,
+	}, nil
 }
 
+func (s *fcmService) Ready() error {
+	if s.messagingClient == nil {
+		return fmt.Errorf("firebase messaging client not initialized")
+	}
+	return nil
+}
 
 func (s *fcmService) SendNotification(ctx context.Context, token string, title, body string, data map[string]string) error {
 	if token == "" {
@@ -33,42 +71,107 @@ func (s *fcmService) SendNotification(ctx context.Context, token string, title,
 	}
 
 
-	message := &messaging.Message{
-		Token: token, 
+	message := buildMessage(PushMessage{Token: token, Title: title, Body: body, Data: data})
+
+	response, err := s.messagingClient.Send(ctx, message)
+	if err != nil {
+		if isDeadTokenError(err) {
+			s.pruneTokens(ctx, []string{token})
+			return fmt.Errorf("%w: %v", domain.ErrDeviceTokenInvalid, err)
+		}
+		slog.WarnContext(ctx, "Failed to send FCM message", "token", logging.RedactToken(token), "error", err)
+		return fmt.Errorf("FCM send failed: %w", err)
+	}
+
+
+	slog.DebugContext(ctx, "Sent FCM message", "token", logging.RedactToken(token), "message_id", response)
+	return nil
+}
+
+
+// isDeadTokenError reports whether FCM rejected the token itself, meaning the
+// app was uninstalled or the token belongs to another sender, rather than
+// failing transiently. INVALID_ARGUMENT is deliberately excluded: FCM also
+// returns it for malformed payloads, which says nothing about the device.
+func isDeadTokenError(err error) bool {
+	return messaging.IsUnregistered(err) || messaging.IsSenderIDMismatch(err)
+}
+
+func (s *fcmService) pruneTokens(ctx context.Context, tokens []string) {
+	if s.tokenStore == nil || len(tokens) == 0 {
+		return
+	}
+	if err := s.tokenStore.RemoveDeviceTokens(ctx, tokens); err != nil {
+		slog.ErrorContext(ctx, "Failed to prune invalid FCM tokens", "count", len(tokens), "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Pruned invalid FCM tokens", "count", len(tokens))
+}
+
+
+// SendBatch delivers messages through SendEach in chunks of MaxBatchSize and
+// returns one result per message, in order. Dead tokens are pruned before returning.
+func (s *fcmService) SendBatch(ctx context.Context, messages []PushMessage) []SendResult {
+	results := make([]SendResult, len(messages))
+	var deadTokens []string
+
+	for start := 0; start < len(messages); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+		chunk := messages[start:end]
+
+		fcmMessages := make([]*messaging.Message, len(chunk))
+		for i, m := range chunk {
+			results[start+i].Token = m.Token
+			fcmMessages[i] = buildMessage(m)
+		}
+
+		batchResp, err := s.messagingClient.SendEach(ctx, fcmMessages)
+		if err != nil {
+			slog.ErrorContext(ctx, "FCM batch send failed", "count", len(chunk), "error", err)
+			for i := range chunk {
+				results[start+i].Err = fmt.Errorf("FCM batch send failed: %w", err)
+			}
+			continue
+		}
+
+		for i, resp := range batchResp.Responses {
+			switch {
+			case resp.Success:
+				results[start+i].MessageID = resp.MessageID
+			case isDeadTokenError(resp.Error):
+				deadTokens = append(deadTokens, chunk[i].Token)
+				results[start+i].Err = fmt.Errorf("%w: %v", domain.ErrDeviceTokenInvalid, resp.Error)
+			default:
+				results[start+i].Err = fmt.Errorf("FCM send failed: %w", resp.Error)
+			}
+		}
+		slog.InfoContext(ctx, "FCM batch sent", "succeeded", batchResp.SuccessCount, "failed", batchResp.FailureCount)
+	}
+
+	s.pruneTokens(ctx, deadTokens)
+	return results
+}
+
+func buildMessage(m PushMessage) *messaging.Message {
+	return &messaging.Message{
+		Token: m.Token,
 		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
+			Title: m.Title,
+			Body:  m.Body,
 		},
-		Data: data, 
-
-	
+		Data: m.Data,
 		Android: &messaging.AndroidConfig{
 			Priority: "high",
 		},
-		
 		APNS: &messaging.APNSConfig{
 			Payload: &messaging.APNSPayload{
 				Aps: &messaging.Aps{
-					
 					ContentAvailable: true,
 				},
 			},
 		},
-	
-	}
-
-	
-	response, err := s.messagingClient.Send(ctx, message)
-	if err != nil {
-		
-		log.Printf("Failed to send FCM message to token %s: %v", token, err)
-		return fmt.Errorf("FCM send failed: %w", err)
 	}
-
-
-	log.Printf("Successfully sent FCM message to token %s. Message ID: %s", token, response)
-	return nil
 }
-
-
-
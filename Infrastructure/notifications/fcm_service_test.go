@@ -0,0 +1,90 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"consistent_1/Domain"
+
+	firebase "firebase.google.com/go/v4"
+	"google.golang.org/api/option"
+)
+
+type recordingTokenStore struct {
+	removed []string
+}
+
+func (s *recordingTokenStore) RemoveDeviceTokens(ctx context.Context, tokens []string) error {
+	s.removed = append(s.removed, tokens...)
+	return nil
+}
+
+// newFCMStub serves the FCM v1 send endpoint the way FCM_ENDPOINT points the
+// SDK at it, rejecting the token "dead" as unregistered.
+func newFCMStub(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/consistify-test/messages:send" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Message.Token == "dead" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",` +
+				`"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`))
+			return
+		}
+		mu.Lock()
+		sent = append(sent, req.Message.Token)
+		mu.Unlock()
+		w.Write([]byte(`{"name":"projects/consistify-test/messages/` + req.Message.Token + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &sent
+}
+
+func TestFCMServiceSendBatch(t *testing.T) {
+	server, sent := newFCMStub(t)
+	app, err := firebase.NewApp(context.Background(), &firebase.Config{ProjectID: "consistify-test"},
+		option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &recordingTokenStore{}
+	service, err := NewFCMService(app, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := service.SendBatch(context.Background(), []PushMessage{
+		{Token: "live", Title: "Reminder", Body: "Solve a problem"},
+		{Token: "dead", Title: "Reminder", Body: "Solve a problem"},
+	})
+
+	if results[0].Err != nil || results[0].MessageID != "projects/consistify-test/messages/live" {
+		t.Errorf("live result = %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, domain.ErrDeviceTokenInvalid) {
+		t.Errorf("dead result error = %v, want %v", results[1].Err, domain.ErrDeviceTokenInvalid)
+	}
+	if len(*sent) != 1 || (*sent)[0] != "live" {
+		t.Errorf("delivered tokens = %v, want [live]", *sent)
+	}
+	if len(store.removed) != 1 || store.removed[0] != "dead" {
+		t.Errorf("pruned tokens = %v, want [dead]", store.removed)
+	}
+}
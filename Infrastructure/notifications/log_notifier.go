@@ -0,0 +1,33 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+)
+
+type logNotifier struct {
+	channel string
+}
+
+// NewLogNotifier stands in for a channel's transport when running offline:
+// every delivery is logged and reported as sent.
+func NewLogNotifier(channel string) Notifier {
+	return &logNotifier{channel: channel}
+}
+
+func (n *logNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *logNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	for _, d := range deliveries {
+		slog.InfoContext(ctx, "Notification delivered to log",
+			"channel", n.channel,
+			"user_id", d.User.ID.Hex(),
+			"type", d.Notification.Type,
+			"title", d.Notification.Title,
+			"body", d.Notification.Body,
+		)
+	}
+	return make([]error, len(deliveries))
+}
@@ -0,0 +1,30 @@
+package notifications
+
+import (
+	"context"
+
+	"consistent_1/Domain"
+)
+
+type noopNotifier struct {
+	channel string
+}
+
+// NewNoopNotifier stands in for a channel whose transport is not configured
+// on this server. Every delivery fails with domain.ErrChannelNotConfigured,
+// which the outbox records as skipped instead of retrying.
+func NewNoopNotifier(channel string) Notifier {
+	return &noopNotifier{channel: channel}
+}
+
+func (n *noopNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *noopNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	errs := make([]error, len(deliveries))
+	for i := range errs {
+		errs[i] = domain.ErrChannelNotConfigured
+	}
+	return errs
+}
@@ -0,0 +1,32 @@
+package notifications
+
+import (
+	"context"
+
+	"consistent_1/Domain"
+)
+
+// Notification is a channel-agnostic message. Each Notifier renders it in the
+// form its transport expects.
+type Notification struct {
+	Type  string
+	Kind  string // one of the domain.NotificationKind* values, used for user preferences
+	Title string
+	Body  string
+	// HTMLBody is an optional rich rendering used by channels that support it.
+	HTMLBody string
+	Data     map[string]string
+}
+
+// Delivery pairs a notification with the user it is addressed to.
+type Delivery struct {
+	User         *domain.User
+	Notification Notification
+}
+
+// Notifier delivers notifications over a single channel. Notify returns one
+// error slot per delivery, in order, so callers can tell which recipients failed.
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, deliveries []Delivery) []error
+}
@@ -0,0 +1,99 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// DefaultTelegramAPIBaseURL is the public Bot API endpoint.
+const DefaultTelegramAPIBaseURL = "https://api.telegram.org"
+
+type telegramNotifier struct {
+	baseURL    string
+	botToken   string
+	httpClient *http.Client
+}
+
+// NewTelegramNotifier sends messages through the Bot API at baseURL, which
+// defaults to DefaultTelegramAPIBaseURL when empty.
+func NewTelegramNotifier(baseURL, botToken string) Notifier {
+	if baseURL == "" {
+		baseURL = DefaultTelegramAPIBaseURL
+	}
+	return &telegramNotifier{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		botToken:   botToken,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *telegramNotifier) Channel() string {
+	return domain.ChannelTelegram
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	errs := make([]error, len(deliveries))
+	for i, d := range deliveries {
+		errs[i] = n.send(ctx, d.User.TelegramChatID, d.Notification)
+	}
+	return errs
+}
+
+type telegramSendMessageResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description,omitempty"`
+}
+
+func (n *telegramNotifier) send(ctx context.Context, chatID string, notification Notification) error {
+	if chatID == "" {
+		return domain.ErrRecipientNotConfigured
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"chat_id": chatID,
+		"text":    notification.Title + "\n\n" + notification.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram message: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", n.baseURL, n.botToken)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Telegram request: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make Telegram request: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+
+	var tgResp telegramSendMessageResponse
+	body, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &tgResp); err != nil || !tgResp.OK {
+		return fmt.Errorf("Telegram API responded with status %d: %s", resp.StatusCode, tgResp.Description)
+	}
+	return nil
+}
+
+// withoutURL strips the request URL from err. Bot API URLs embed the bot
+// token, and these errors end up in logs and in the outbox's lastError.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"consistent_1/Domain"
+)
+
+func TestTelegramNotifierSendsMessage(t *testing.T) {
+	var path string
+	var request map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer server.Close()
+
+	errs := NewTelegramNotifier(server.URL, "123456:token").Notify(context.Background(), []Delivery{{
+		User:         &domain.User{TelegramChatID: "42"},
+		Notification: Notification{Title: "Reminder", Body: "Solve a problem"},
+	}})
+	if errs[0] != nil {
+		t.Fatalf("Notify() error = %v", errs[0])
+	}
+	if path != "/bot123456:token/sendMessage" {
+		t.Errorf("path = %q, want /bot123456:token/sendMessage", path)
+	}
+	if request["chat_id"] != "42" || request["text"] != "Reminder\n\nSolve a problem" {
+		t.Errorf("request = %v", request)
+	}
+}
+
+func TestTelegramNotifierReportsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	errs := NewTelegramNotifier(server.URL, "123456:token").Notify(context.Background(), []Delivery{{
+		User: &domain.User{TelegramChatID: "42"},
+	}})
+	if errs[0] == nil || !strings.Contains(errs[0].Error(), "chat not found") {
+		t.Fatalf("Notify() error = %v, want the API description", errs[0])
+	}
+}
+
+func TestTelegramNotifierKeepsTokenOutOfErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := server.URL
+	server.Close()
+
+	errs := NewTelegramNotifier(baseURL, "123456:secret-token").Notify(context.Background(), []Delivery{{
+		User: &domain.User{TelegramChatID: "42"},
+	}})
+	if errs[0] == nil {
+		t.Fatal("Notify() error = nil, want a connection error")
+	}
+	if strings.Contains(errs[0].Error(), "secret-token") {
+		t.Fatalf("Notify() error %q contains the bot token", errs[0])
+	}
+}
+
+func TestTelegramNotifierSkipsUsersWithoutChat(t *testing.T) {
+	errs := NewTelegramNotifier("http://127.0.0.1:1", "123456:token").Notify(context.Background(), []Delivery{{
+		User: &domain.User{},
+	}})
+	if !errors.Is(errs[0], domain.ErrRecipientNotConfigured) {
+		t.Fatalf("Notify() error = %v, want %v", errs[0], domain.ErrRecipientNotConfigured)
+	}
+}
@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body when
+// a signing secret is configured, so receivers can verify the sender.
+const WebhookSignatureHeader = "X-Consistify-Signature"
+
+// errWebhookAddressForbidden is returned when a webhook URL resolves to an
+// address inside our own network, which users must not be able to reach.
+var errWebhookAddressForbidden = errors.New("webhook address is not publicly routable")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// net.IP.IsPrivate does not cover.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+type webhookNotifier struct {
+	signingSecret string
+	httpClient    *http.Client
+}
+
+// NewWebhookNotifier POSTs a JSON payload to each user's own WebhookURL.
+// Since the URL is user supplied, the client only connects to public
+// addresses, checked after DNS resolution, and never follows redirects.
+// allowPrivate lifts the address check so local stub servers can receive
+// webhooks; it must stay off in production.
+func NewWebhookNotifier(signingSecret string, allowPrivate bool) Notifier {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = webhookDialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &webhookNotifier{
+		signingSecret: signingSecret,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// webhookDialControl refuses connections to loopback, private, shared (CGNAT),
+// link-local, multicast and unspecified addresses, such as the cloud metadata
+// endpoint or the database on localhost.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", errWebhookAddressForbidden, host)
+	}
+	return nil
+}
+
+func (n *webhookNotifier) Channel() string {
+	return domain.ChannelWebhook
+}
+
+type webhookPayload struct {
+	Type   string            `json:"type"`
+	UserID string            `json:"userId"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+	SentAt time.Time         `json:"sentAt"`
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, deliveries []Delivery) []error {
+	errs := make([]error, len(deliveries))
+	for i, d := range deliveries {
+		errs[i] = n.send(ctx, d.User, d.Notification)
+	}
+	return errs
+}
+
+func (n *webhookNotifier) send(ctx context.Context, user *domain.User, notification Notification) error {
+	if user.WebhookURL == "" {
+		return domain.ErrRecipientNotConfigured
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Type:   notification.Type,
+		UserID: user.ID.Hex(),
+		Title:  notification.Title,
+		Body:   notification.Body,
+		Data:   notification.Data,
+		SentAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", user.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Consistify-Backend/1.0")
+	if n.signingSecret != "" {
+		mac := hmac.New(sha256.New, []byte(n.signingSecret))
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make webhook request: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWebhookNotifierRefusesLoopback(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	user := &domain.User{ID: primitive.NewObjectID(), WebhookURL: server.URL}
+	errs := NewWebhookNotifier("", false).Notify(context.Background(), []Delivery{
+		{User: user, Notification: Notification{Type: "reminder", Title: "t", Body: "b"}},
+	})
+
+	if !errors.Is(errs[0], errWebhookAddressForbidden) {
+		t.Fatalf("Notify() error = %v, want %v", errs[0], errWebhookAddressForbidden)
+	}
+	if hit {
+		t.Fatal("webhook request reached the loopback server")
+	}
+}
+
+func TestWebhookDialControlRefusesInternalAddresses(t *testing.T) {
+	for _, address := range []string{
+		"127.0.0.1:80",
+		"[::1]:80",
+		"10.1.2.3:443",
+		"172.16.0.1:443",
+		"192.168.1.1:443",
+		"100.64.0.1:443",
+		"169.254.169.254:80",
+		"0.0.0.0:80",
+		"[fe80::1]:80",
+	} {
+		if err := webhookDialControl("tcp", address, nil); !errors.Is(err, errWebhookAddressForbidden) {
+			t.Errorf("webhookDialControl(%s) = %v, want %v", address, err, errWebhookAddressForbidden)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "100.128.0.1:443", "[2606:4700::1111]:443"} {
+		if err := webhookDialControl("tcp", address, nil); err != nil {
+			t.Errorf("webhookDialControl(%s) = %v, want nil", address, err)
+		}
+	}
+}
+
+func TestWebhookNotifierPostsSignedPayload(t *testing.T) {
+	var body []byte
+	var signature, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	user := &domain.User{ID: primitive.NewObjectID(), WebhookURL: server.URL}
+	errs := NewWebhookNotifier("secret", true).Notify(context.Background(), []Delivery{{
+		User: user,
+		Notification: Notification{
+			Type:  "reminder",
+			Title: "Reminder",
+			Body:  "Solve a problem",
+			Data:  map[string]string{"date": "2026-10-19"},
+		},
+	}})
+	if errs[0] != nil {
+		t.Fatalf("Notify() error = %v", errs[0])
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("%s = %q, want %q", WebhookSignatureHeader, signature, want)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if payload.Type != "reminder" || payload.UserID != user.ID.Hex() || payload.Title != "Reminder" ||
+		payload.Body != "Solve a problem" || payload.Data["date"] != "2026-10-19" || payload.SentAt.IsZero() {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestWebhookNotifierFailsOnNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	user := &domain.User{ID: primitive.NewObjectID(), WebhookURL: server.URL}
+	errs := NewWebhookNotifier("", true).Notify(context.Background(), []Delivery{
+		{User: user, Notification: Notification{Type: "reminder"}},
+	})
+	if errs[0] == nil {
+		t.Fatal("Notify() error = nil, want an error for status 502")
+	}
+}
+
+func TestWebhookNotifierDoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	user := &domain.User{ID: primitive.NewObjectID(), WebhookURL: server.URL}
+	errs := NewWebhookNotifier("", true).Notify(context.Background(), []Delivery{
+		{User: user, Notification: Notification{Type: "reminder"}},
+	})
+	if errs[0] == nil {
+		t.Fatal("Notify() error = nil, want an error for the redirect")
+	}
+	if redirected {
+		t.Fatal("webhook request followed the redirect")
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"consistent_1/Domain" 
@@ -17,13 +19,25 @@ import (
 type CodeforcesAPIClient struct {
 	baseURL    string
 	httpClient *http.Client
+	guard      *Guard
 }
-func NewCodeforcesAPI(baseURL string) CodeforcesAPI {
+// NewCodeforcesAPI sends every request through guard, which owns rate limiting,
+// retries and per-attempt timeouts.
+func NewCodeforcesAPI(baseURL string, guard *Guard) CodeforcesAPI {
 	return &CodeforcesAPIClient{
 		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{Transport: guard},
+		guard:      guard,
 	}
 }
+
+func (api *CodeforcesAPIClient) Status() domain.PlatformStatus {
+	return api.guard.Status()
+}
+// CodeforcesHardRating is the problem rating from which a Codeforces solve
+// counts as Hard.
+const CodeforcesHardRating = 1900
+
 type CodeforcesSubmission struct {
 	ID                  int         `json:"id"`
 	ContestID           int         `json:"contestId"`
@@ -75,7 +89,7 @@ func (api *CodeforcesAPIClient) FetchUserDailyActivity(ctx context.Context, user
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("Codeforces API error response (%d): %s", resp.StatusCode, string(respBody))
+		slog.WarnContext(ctx, "Codeforces API error response", "status", resp.StatusCode, "body", string(respBody))
 		return domain.PlatformActivity{}, fmt.Errorf("Codeforces API responded with status %d: %s", resp.StatusCode, string(respBody))
 	}
 
@@ -89,6 +103,7 @@ func (api *CodeforcesAPIClient) FetchUserDailyActivity(ctx context.Context, user
 	}
 
 	problemsSolvedToday := 0
+	hardSolvedToday := 0
 	isConsistent := false
 	uniqueProblemIDsToday := make(map[string]bool) 
 	startOfDayUTC := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
@@ -100,6 +115,9 @@ func (api *CodeforcesAPIClient) FetchUserDailyActivity(ctx context.Context, user
 			problemIdentifier := fmt.Sprintf("%d-%s", sub.Problem.ContestID, sub.Problem.Index)
 			if !uniqueProblemIDsToday[problemIdentifier] {
 				problemsSolvedToday++
+				if sub.Problem.Rating >= CodeforcesHardRating {
+					hardSolvedToday++
+				}
 				uniqueProblemIDsToday[problemIdentifier] = true
 			}
 			isConsistent = true 
@@ -112,5 +130,48 @@ func (api *CodeforcesAPIClient) FetchUserDailyActivity(ctx context.Context, user
 		Date:           date.Truncate(24 * time.Hour), 
 		IsConsistent:   isConsistent,
 		ProblemsSolved: problemsSolvedToday,
+		HardSolved:     hardSolvedToday,
 	}, nil
-}
\ No newline at end of file
+}
+
+type CodeforcesUserInfoResponse struct {
+	Status  string `json:"status"`
+	Result  []struct {
+		Handle string `json:"handle"`
+	} `json:"result"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ValidateUsername checks via user.info that the handle exists on Codeforces.
+func (api *CodeforcesAPIClient) ValidateUsername(ctx context.Context, username string) error {
+	endpoint := fmt.Sprintf("%s/api/user.info?handles=%s", api.baseURL, url.QueryEscape(username))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Codeforces request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Consistify-Backend/1.0")
+
+	resp, err := api.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to make Codeforces request: %v", domain.ErrExternalAPIFailed, err)
+	}
+	defer resp.Body.Close()
+
+	// Codeforces answers unknown handles with 400 and status FAILED, so the
+	// body is decoded before the status code is checked.
+	var cfResp CodeforcesUserInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cfResp); err != nil {
+		return fmt.Errorf("%w: failed to decode Codeforces response (status %d): %v", domain.ErrExternalAPIFailed, resp.StatusCode, err)
+	}
+	if cfResp.Status != "OK" {
+		if strings.Contains(strings.ToLower(cfResp.Comment), "not found") {
+			return domain.ErrPlatformUserNotFound
+		}
+		return fmt.Errorf("%w: Codeforces API error: %s", domain.ErrExternalAPIFailed, cfResp.Comment)
+	}
+	if len(cfResp.Result) == 0 {
+		return domain.ErrPlatformUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package platform_api
+
+import (
+	"context"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// DisabledAPI stands in for a platform client turned off in configuration.
+// It satisfies both LeetCodeAPI and CodeforcesAPI and fails every call with
+// domain.ErrPlatformDisabled.
+type DisabledAPI struct {
+	platform string
+}
+
+func NewDisabledAPI(platform string) *DisabledAPI {
+	return &DisabledAPI{platform: platform}
+}
+
+func (api *DisabledAPI) FetchUserDailyActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error) {
+	return domain.PlatformActivity{}, domain.ErrPlatformDisabled
+}
+
+func (api *DisabledAPI) ValidateUsername(ctx context.Context, username string) error {
+	return domain.ErrPlatformDisabled
+}
+
+func (api *DisabledAPI) Status() domain.PlatformStatus {
+	return domain.PlatformStatus{Platform: api.platform, Disabled: true}
+}
@@ -0,0 +1,166 @@
+package platform_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+// PlatformFixtures maps platform name to username to that user's activity,
+// as read from a fixtures file:
+//
+//	{
+//	  "leetcode": {
+//	    "alice": {
+//	      "default": {"problemsSolved": 1},
+//	      "days": {"2026-10-18": {"problemsSolved": 0}, "today": {"error": "timeout"}}
+//	    }
+//	  }
+//	}
+//
+// Usernames missing from a platform do not exist on it.
+type PlatformFixtures map[string]map[string]FixtureUser
+
+// FixtureUser is one username's activity. Days are keyed by UTC date, or by
+// "today" or "today-N" for days relative to the current one; any other day
+// uses Default.
+type FixtureUser struct {
+	Default FixtureDay            `json:"default"`
+	Days    map[string]FixtureDay `json:"days"`
+}
+
+// FixtureDay is what a platform reports for one day. A non-empty Error fails
+// the fetch with that message, as an unreachable platform would.
+type FixtureDay struct {
+	ProblemsSolved int    `json:"problemsSolved"`
+	HardSolved     int    `json:"hardSolved"`
+	Error          string `json:"error"`
+}
+
+// LoadPlatformFixtures reads a fixtures file in the format described at
+// PlatformFixtures.
+func LoadPlatformFixtures(path string) (PlatformFixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures PlatformFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("parsing platform fixtures %s: %w", path, err)
+	}
+	for platform, users := range fixtures {
+		if !domain.SupportedPlatforms[platform] {
+			return nil, fmt.Errorf("platform fixtures %s: unsupported platform %q", path, platform)
+		}
+		for username, user := range users {
+			for key := range user.Days {
+				if !validFixtureDay(key) {
+					return nil, fmt.Errorf("platform fixtures %s: %s/%s: invalid day %q, expected YYYY-MM-DD, today or today-N", path, platform, username, key)
+				}
+			}
+		}
+	}
+	return fixtures, nil
+}
+
+// fixtureEpoch is the first day counted towards the lifetime totals of
+// platforms that only report those, such as LeetCode.
+var fixtureEpoch = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func validFixtureDay(key string) bool {
+	if key == "today" {
+		return true
+	}
+	if n, ok := strings.CutPrefix(key, "today-"); ok {
+		offset, err := strconv.Atoi(n)
+		return err == nil && offset > 0
+	}
+	_, err := time.Parse("2006-01-02", key)
+	return err == nil
+}
+
+// on returns what the platform reports for the user on the UTC day of date.
+func (u FixtureUser) on(date, today time.Time) FixtureDay {
+	if day, ok := u.Days[date.Format("2006-01-02")]; ok {
+		return day
+	}
+	key := "today"
+	if offset := int(today.Sub(date).Hours() / 24); offset > 0 {
+		key = fmt.Sprintf("today-%d", offset)
+	} else if offset < 0 {
+		return u.Default
+	}
+	if day, ok := u.Days[key]; ok {
+		return day
+	}
+	return u.Default
+}
+
+// totalsThrough sums the user's solved and hard problems from fixtureEpoch up
+// to and including date. Days that fail count nothing.
+func (u FixtureUser) totalsThrough(date, today time.Time) (solved, hard int) {
+	for d := fixtureEpoch; !d.After(date); d = d.AddDate(0, 0, 1) {
+		if day := u.on(d, today); day.Error == "" {
+			solved += day.ProblemsSolved
+			hard += day.HardSolved
+		}
+	}
+	return solved, hard
+}
+
+// FixtureAPI serves one platform's activity from fixtures instead of the
+// network, for running the service offline. It satisfies both LeetCodeAPI and
+// CodeforcesAPI. Like the live LeetCode API, LeetCode fixtures report lifetime
+// totals, which the consistency check turns into per-day deltas.
+type FixtureAPI struct {
+	platform   string
+	users      map[string]FixtureUser
+	cumulative bool
+}
+
+func NewFixtureAPI(platform string, fixtures PlatformFixtures) *FixtureAPI {
+	return &FixtureAPI{platform: platform, users: fixtures[platform], cumulative: platform == "leetcode"}
+}
+
+func (api *FixtureAPI) FetchUserDailyActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error) {
+	user, ok := api.users[username]
+	if !ok {
+		return domain.PlatformActivity{}, domain.ErrPlatformUserNotFound
+	}
+	date, today := domain.StreakDay(date), domain.StreakDay(time.Now())
+	day := user.on(date, today)
+	if day.Error != "" {
+		return domain.PlatformActivity{}, errors.New(day.Error)
+	}
+	activity := domain.PlatformActivity{
+		Platform:       api.platform,
+		Username:       username,
+		Date:           date,
+		IsConsistent:   day.ProblemsSolved > 0,
+		ProblemsSolved: day.ProblemsSolved,
+		HardSolved:     day.HardSolved,
+	}
+	if api.cumulative {
+		activity.IsConsistent = false
+		activity.ProblemsSolved, activity.HardSolved = user.totalsThrough(date, today)
+	}
+	return activity, nil
+}
+
+func (api *FixtureAPI) ValidateUsername(ctx context.Context, username string) error {
+	if _, ok := api.users[username]; !ok {
+		return domain.ErrPlatformUserNotFound
+	}
+	return nil
+}
+
+func (api *FixtureAPI) Status() domain.PlatformStatus {
+	return domain.PlatformStatus{Platform: api.platform, Breaker: domain.BreakerClosed}
+}
@@ -0,0 +1,303 @@
+package platform_api
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/metrics"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/time/rate"
+)
+
+// GuardConfig tunes the outbound protection for one platform.
+type GuardConfig struct {
+	RequestsPerSecond float64       // token refill rate
+	Burst             int           // bucket size
+	AttemptTimeout    time.Duration // cap on a single HTTP attempt
+	MaxRetries        int           // retries after the first attempt on 429/5xx or network errors
+	BaseBackoff       time.Duration // first retry delay, doubled per retry and jittered
+	MaxBackoff        time.Duration
+	FailureThreshold  int           // consecutive failed requests that open the breaker
+	OpenTimeout       time.Duration // how long the breaker stays open before a probe
+}
+
+// DefaultGuardConfig suits an API that tolerates rps requests per second.
+func DefaultGuardConfig(rps float64) GuardConfig {
+	burst := int(rps)
+	if burst < 1 {
+		burst = 1
+	}
+	return GuardConfig{
+		RequestsPerSecond: rps,
+		Burst:             burst,
+		AttemptTimeout:    10 * time.Second,
+		MaxRetries:        3,
+		BaseBackoff:       500 * time.Millisecond,
+		MaxBackoff:        10 * time.Second,
+		FailureThreshold:  5,
+		OpenTimeout:       time.Minute,
+	}
+}
+
+// Guard is an http.RoundTripper that rate limits, retries and circuit-breaks
+// every request to one platform. Clients share a Guard per platform so the
+// limit holds across all of them.
+type Guard struct {
+	platform string
+	config   GuardConfig
+	limiter  *rate.Limiter
+	next     http.RoundTripper
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+	recent   errorWindow
+}
+
+// errorWindowMinutes is how far back Status reports the platform's error rate.
+const errorWindowMinutes = 15
+
+// errorWindow counts outcomes in one-minute buckets over the last
+// errorWindowMinutes.
+type errorWindow struct {
+	buckets [errorWindowMinutes]struct {
+		minute             int64
+		requests, failures int
+	}
+}
+
+func (w *errorWindow) add(now time.Time, failed bool) {
+	minute := now.Unix() / 60
+	b := &w.buckets[minute%errorWindowMinutes]
+	if b.minute != minute {
+		b.minute, b.requests, b.failures = minute, 0, 0
+	}
+	b.requests++
+	if failed {
+		b.failures++
+	}
+}
+
+func (w *errorWindow) totals(now time.Time) (requests, failures int) {
+	minute := now.Unix() / 60
+	for _, b := range w.buckets {
+		if minute-b.minute < errorWindowMinutes {
+			requests += b.requests
+			failures += b.failures
+		}
+	}
+	return requests, failures
+}
+
+func NewGuard(platform string, config GuardConfig) *Guard {
+	return &Guard{
+		platform: platform,
+		config:   config,
+		limiter:  rate.NewLimiter(rate.Limit(config.RequestsPerSecond), config.Burst),
+		// Each attempt, including retries, gets its own client span.
+		next: otelhttp.NewTransport(http.DefaultTransport,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return platform + " " + r.Method
+			})),
+		state: domain.BreakerClosed,
+	}
+}
+
+func (g *Guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := g.allow(); err != nil {
+		metrics.PlatformRequests.WithLabelValues(g.platform, "rejected").Inc()
+		return nil, err
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = g.limiter.Wait(req.Context()); err != nil {
+			g.release()
+			return nil, err
+		}
+		resp, err = g.attempt(req, attempt)
+		if !retryable(resp, err) || attempt >= g.config.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		delay := g.backoff(attempt, resp)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			g.release()
+			return nil, req.Context().Err()
+		}
+	}
+
+	if req.Context().Err() != nil {
+		g.release()
+	} else {
+		g.record(!retryable(resp, err))
+	}
+	return resp, err
+}
+
+// attempt sends one copy of req under AttemptTimeout. The timeout is released
+// when the response body is closed.
+func (g *Guard) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), g.config.AttemptTimeout)
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	started := time.Now()
+	resp, err := g.next.RoundTrip(r)
+	metrics.PlatformRequestDuration.WithLabelValues(g.platform).Observe(metrics.Since(started))
+	metrics.PlatformRequests.WithLabelValues(g.platform, attemptOutcome(resp, err)).Inc()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// attemptOutcome classifies one attempt for the platform request metrics.
+func attemptOutcome(resp *http.Response, err error) string {
+	switch {
+	case err != nil:
+		return "network_error"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "throttled"
+	case resp.StatusCode >= 500:
+		return "server_error"
+	case resp.StatusCode >= 400:
+		return "client_error"
+	default:
+		return "ok"
+	}
+}
+
+// retryable reports whether the outcome is worth retrying and counts against
+// the breaker: network errors, throttling and server errors.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff honours Retry-After when given, otherwise doubles BaseBackoff per
+// attempt and picks a random delay in its upper half.
+func (g *Guard) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if delay := time.Duration(seconds) * time.Second; delay < g.config.MaxBackoff {
+				return delay
+			}
+			return g.config.MaxBackoff
+		}
+	}
+	delay := g.config.BaseBackoff << uint(attempt)
+	if delay <= 0 || delay > g.config.MaxBackoff {
+		delay = g.config.MaxBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// allow admits a request unless the breaker is open. Once OpenTimeout has
+// passed a single probe is let through; its outcome closes or reopens the breaker.
+func (g *Guard) allow() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case domain.BreakerOpen:
+		if time.Since(g.openedAt) < g.config.OpenTimeout {
+			return domain.ErrPlatformUnavailable
+		}
+		g.state = domain.BreakerHalfOpen
+		fallthrough
+	case domain.BreakerHalfOpen:
+		if g.probing {
+			return domain.ErrPlatformUnavailable
+		}
+		g.probing = true
+	}
+	return nil
+}
+
+func (g *Guard) record(success bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.probing = false
+	g.recent.add(time.Now(), !success)
+	if success {
+		g.state = domain.BreakerClosed
+		g.failures = 0
+		return
+	}
+	g.failures++
+	if g.state == domain.BreakerHalfOpen || g.failures >= g.config.FailureThreshold {
+		g.state = domain.BreakerOpen
+		g.openedAt = time.Now()
+	}
+}
+
+// release ends a request the caller abandoned without judging the platform.
+func (g *Guard) release() {
+	g.mu.Lock()
+	g.probing = false
+	g.mu.Unlock()
+}
+
+// Status reports the breaker and limiter state for the admin API.
+func (g *Guard) Status() domain.PlatformStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := domain.PlatformStatus{
+		Platform:            g.platform,
+		Breaker:             g.state,
+		ConsecutiveFailures: g.failures,
+		RequestsPerSecond:   g.config.RequestsPerSecond,
+		Burst:               g.config.Burst,
+		WindowMinutes:       errorWindowMinutes,
+	}
+	status.RecentRequests, status.RecentFailures = g.recent.totals(time.Now())
+	if status.RecentRequests > 0 {
+		status.ErrorRate = float64(status.RecentFailures) / float64(status.RecentRequests)
+	}
+	if g.state != domain.BreakerClosed {
+		openedAt := g.openedAt
+		retryAt := openedAt.Add(g.config.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
@@ -161,7 +161,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 	"bytes"
@@ -173,16 +173,24 @@ import (
 type LeetCodeAPIClient struct {
 	baseURL    string
 	httpClient *http.Client
+	guard      *Guard
 }
 
 
-func NewLeetCodeAPI(baseURL string) LeetCodeAPI {
+// NewLeetCodeAPI sends every request through guard, which owns rate limiting,
+// retries and per-attempt timeouts.
+func NewLeetCodeAPI(baseURL string, guard *Guard) LeetCodeAPI {
 	return &LeetCodeAPIClient{
 		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: &http.Client{Transport: guard},
+		guard:      guard,
 	}
 }
 
+func (api *LeetCodeAPIClient) Status() domain.PlatformStatus {
+	return api.guard.Status()
+}
+
 
 const leetcodeGraphQLQuery = `
 query userSolutionNum($username: String!) {
@@ -225,7 +233,7 @@ func (api *LeetCodeAPIClient) FetchUserDailyActivity(ctx context.Context, userna
 		return domain.PlatformActivity{}, fmt.Errorf("failed to marshal LeetCode GraphQL request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://leetcode.com/graphql", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", api.baseURL+"/graphql", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return domain.PlatformActivity{}, fmt.Errorf("failed to create LeetCode GraphQL request: %w", err)
 	}
@@ -240,7 +248,7 @@ func (api *LeetCodeAPIClient) FetchUserDailyActivity(ctx context.Context, userna
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := ioutil.ReadAll(resp.Body)
-		log.Printf("LeetCode API error response (%d) for user %s: %s", resp.StatusCode, username, string(respBody))
+		slog.WarnContext(ctx, "LeetCode API error response", "status", resp.StatusCode, "username", username, "body", string(respBody))
 		return domain.PlatformActivity{}, fmt.Errorf("LeetCode API responded with status %d", resp.StatusCode)
 	}
 
@@ -258,14 +266,84 @@ func (api *LeetCodeAPIClient) FetchUserDailyActivity(ctx context.Context, userna
 	}
 
 	totalProblemsSolved := 0
+	hardProblemsSolved := 0
 	for _, stat := range graphQLResp.Data.MatchedUser.SubmitStats.AcSubmissionNum {
 		totalProblemsSolved += stat.Count
+		if stat.Difficulty == "Hard" {
+			hardProblemsSolved = stat.Count
+		}
 	}
+	// Like ProblemsSolved, HardSolved is the lifetime count here; the
+	// consistency check turns both into per-day deltas.
 	return domain.PlatformActivity{
 		Platform:       "leetcode",
 		Username:       username,
 		Date:           date.Truncate(24 * time.Hour),
 		IsConsistent:   false, 
 		ProblemsSolved: totalProblemsSolved, 
+		HardSolved:     hardProblemsSolved,
 	}, nil
-}
\ No newline at end of file
+}
+
+const leetcodeMatchedUserQuery = `
+query getUserProfile($username: String!) {
+    matchedUser(username: $username) {
+        username
+    }
+}
+`
+
+type LeetCodeMatchedUserResponse struct {
+	Data struct {
+		MatchedUser *struct {
+			Username string `json:"username"`
+		} `json:"matchedUser"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// ValidateUsername checks via matchedUser that the handle exists on LeetCode.
+func (api *LeetCodeAPIClient) ValidateUsername(ctx context.Context, username string) error {
+	requestBody := map[string]interface{}{
+		"query": leetcodeMatchedUserQuery,
+		"variables": map[string]interface{}{
+			"username": username,
+		},
+		"operationName": "getUserProfile",
+	}
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal LeetCode GraphQL request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", api.baseURL+"/graphql", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create LeetCode GraphQL request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Consistify-Backend/1.0")
+
+	resp, err := api.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to make LeetCode GraphQL request: %v", domain.ErrExternalAPIFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: LeetCode API responded with status %d", domain.ErrExternalAPIFailed, resp.StatusCode)
+	}
+
+	var graphQLResp LeetCodeMatchedUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&graphQLResp); err != nil {
+		return fmt.Errorf("%w: failed to decode LeetCode GraphQL response: %v", domain.ErrExternalAPIFailed, err)
+	}
+
+	// LeetCode reports an unknown user as a null matchedUser, usually with a
+	// "That user does not exist." error alongside it.
+	if graphQLResp.Data.MatchedUser == nil {
+		return domain.ErrPlatformUserNotFound
+	}
+	return nil
+}
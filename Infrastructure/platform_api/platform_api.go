@@ -7,8 +7,12 @@ import (
 )
 type LeetCodeAPI interface {
 	FetchUserDailyActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error)
+	ValidateUsername(ctx context.Context, username string) error
+	Status() domain.PlatformStatus
 }
 type CodeforcesAPI interface {
 	FetchUserDailyActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error)
+	ValidateUsername(ctx context.Context, username string) error
+	Status() domain.PlatformStatus
 }
 
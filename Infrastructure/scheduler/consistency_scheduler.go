@@ -5,96 +5,243 @@ package scheduler
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
-	
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/logging"
+	"consistent_1/Infrastructure/tracing"
 	"consistent_1/Usecases"
 
 	"github.com/robfig/cron/v3"
 )
 type ConsistencyScheduler struct {
 	Cron *cron.Cron
+	// ctx is handed to every job. Stop cancels it once running jobs have had
+	// their chance to finish.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopping is closed when Stop begins, so job workers take no new jobs.
+	stopping chan struct{}
+	// elector, when set, restricts jobs to the replica holding the scheduler lease.
+	elector *LeaderElector
+	electorDone chan struct{}
 	ConsistencyUsecase usecases.ConsistencyUsecase
 	UserUsecase        usecases.UserUsecase
+	NotificationUsecase usecases.NotificationUsecase
+	ReportUsecase       usecases.ReportUsecase
+	JobUsecase          usecases.JobUsecase
 }
+// NewConsistencyScheduler runs every job under a context derived from ctx.
 func NewConsistencyScheduler(
+	ctx context.Context,
 	consistencyUsecase usecases.ConsistencyUsecase,
 	userUsecase usecases.UserUsecase,
+	notificationUsecase usecases.NotificationUsecase,
+	reportUsecase usecases.ReportUsecase,
+	jobUsecase usecases.JobUsecase,
+	elector *LeaderElector,
 ) *ConsistencyScheduler {
 	c := cron.New() 
+	ctx, cancel := context.WithCancel(ctx)
 	return &ConsistencyScheduler{
 		Cron: c,
+		ctx:    ctx,
+		cancel: cancel,
+		stopping: make(chan struct{}),
+		elector: elector,
+		electorDone: make(chan struct{}),
 		ConsistencyUsecase: consistencyUsecase,
 		UserUsecase:        userUsecase,
+		NotificationUsecase: notificationUsecase,
+		ReportUsecase:       reportUsecase,
+		JobUsecase:          jobUsecase,
 	}
 }
 func (s *ConsistencyScheduler) Start() {
+	if s.elector != nil {
+		go func() {
+			defer close(s.electorDone)
+			s.elector.Run(s.ctx)
+		}()
+	} else {
+		close(s.electorDone)
+	}
 	s.Cron.Start()
-	log.Println("Consistency scheduler started.")
+	slog.Info("Consistency scheduler started.")
 }
-func (s *ConsistencyScheduler) Stop() {
-	s.Cron.Stop()
-	log.Println("Consistency scheduler stopped.")
+// Stop prevents new runs and waits for running jobs to finish. If ctx ends
+// first, the jobs are cancelled (queued jobs go back on the queue) and Stop
+// waits for them to return. Finally it hands the scheduler lease over.
+func (s *ConsistencyScheduler) Stop(ctx context.Context) {
+	close(s.stopping)
+	done := s.Cron.Stop()
+	select {
+	case <-done.Done():
+	case <-ctx.Done():
+		slog.Warn("Shutdown deadline reached, cancelling running jobs...")
+	}
+	s.cancel()
+	<-done.Done()
+	<-s.electorDone
+	if s.elector != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		s.elector.Release(ctx)
+		cancel()
+	}
+	slog.Info("Consistency scheduler stopped.")
 }
-func (s *ConsistencyScheduler) ScheduleDailyConsistencyCheck() {
-	_, err := s.Cron.AddFunc("5 0 * * *", func() { 
-		log.Println("Running daily consistency check for all users (server time)...")
-		users, err := s.UserUsecase.GetAllUsers(context.Background())
-		if err != nil {
-			log.Printf("Error fetching all users for consistency check: %v", err)
+
+// leaderOnly wraps a job so it runs only on the replica holding the scheduler
+// lease. The job's context ends when the scheduler stops or the lease is lost,
+// and carries a run ID that tags every log line of the run. Each run is
+// traced as a root span called name.
+func (s *ConsistencyScheduler) leaderOnly(name string, job func(ctx context.Context)) func() {
+	run := func(ctx context.Context) {
+		ctx, span := tracing.StartRoot(ctx, name)
+		defer span.End()
+		job(logging.WithRunID(ctx, logging.NewID()))
+	}
+	return func() {
+		if s.elector == nil {
+			run(s.ctx)
 			return
 		}
-
-		for _, user := range users {
-			log.Printf("Checking consistency for user: %s (ID: %s)", user.Email, user.ID.Hex())
-			_, err := s.ConsistencyUsecase.CheckDailyConsistency(context.Background(), user.ID.Hex())
-			if err != nil {
-				log.Printf("Error checking consistency for user %s: %v", user.ID.Hex(), err)
-			}
+		if ctx, ok := s.elector.Lead(s.ctx); ok {
+			run(ctx)
 		}
-	})
+	}
+}
+// ScheduleDailyConsistencyCheck queues the nightly check on spec, by default
+// 00:05 server time. The job survives restarts; see ScheduleJobWorkers.
+func (s *ConsistencyScheduler) ScheduleDailyConsistencyCheck(spec string) error {
+	_, err := s.Cron.AddFunc(spec, s.leaderOnly("enqueue "+domain.JobTypeDailyConsistencyCheck, func(ctx context.Context) {
+		slog.InfoContext(ctx, "Queueing daily consistency check for all users (server time)...")
+		s.enqueue(ctx, domain.JobTypeDailyConsistencyCheck, time.Now().UTC().Format("2006-01-02"))
+	}))
 	if err != nil {
-		log.Fatalf("Error scheduling daily consistency check: %v", err)
+		return fmt.Errorf("scheduling daily consistency check: %w", err)
+	}
+	slog.Info("Daily consistency check scheduled (server time).", "spec", spec)
+	return nil
+}
+// ScheduleNotificationReminders queues a reminder dispatch on spec, by default
+// every minute; each dispatch sends whatever reminders fell due since the
+// previous one.
+func (s *ConsistencyScheduler) ScheduleNotificationReminders(spec string) error {
+	_, err := s.Cron.AddFunc(spec, s.leaderOnly("enqueue "+domain.JobTypeDispatchReminders, func(ctx context.Context) {
+		s.enqueue(ctx, domain.JobTypeDispatchReminders, time.Now().UTC().Format("2006-01-02T15:04"))
+	}))
+	if err != nil {
+		return fmt.Errorf("scheduling notification reminder dispatch: %w", err)
+	}
+	slog.Info("Notification reminder dispatch scheduled.", "spec", spec)
+	return nil
+}
+
+// ScheduleStreakEscalations queues a streak-at-risk dispatch on spec, by
+// default every minute; each dispatch queues the follow-ups that fell due
+// since the previous one.
+func (s *ConsistencyScheduler) ScheduleStreakEscalations(spec string) error {
+	_, err := s.Cron.AddFunc(spec, s.leaderOnly("enqueue "+domain.JobTypeDispatchEscalations, func(ctx context.Context) {
+		s.enqueue(ctx, domain.JobTypeDispatchEscalations, time.Now().UTC().Format("2006-01-02T15:04"))
+	}))
+	if err != nil {
+		return fmt.Errorf("scheduling streak escalation dispatch: %w", err)
+	}
+	slog.Info("Streak escalation dispatch scheduled.", "spec", spec)
+	return nil
+}
+
+// enqueue queues one job per jobType and slot; a slot that is already queued,
+// e.g. by a previous leader, is left alone.
+func (s *ConsistencyScheduler) enqueue(ctx context.Context, jobType, slot string) {
+	_, err := s.JobUsecase.Enqueue(ctx, jobType, nil, jobType+"/"+slot, time.Time{})
+	if err != nil && err != domain.ErrJobDuplicate {
+		slog.ErrorContext(ctx, "Error queueing job", "job_type", jobType, "error", err)
 	}
-	log.Println("Daily consistency check scheduled for 00:05 AM (server time).")
 }
-func (s *ConsistencyScheduler) ScheduleNotificationReminders() {
-	_, err := s.Cron.AddFunc("0 * * * *", func() { 
-		log.Println("Running hourly notification reminder check...")
-		users, err := s.UserUsecase.GetAllUsers(context.Background())
+
+// processJobs runs due jobs until none are left or the scheduler is stopping.
+func (s *ConsistencyScheduler) processJobs() {
+	for {
+		select {
+		case <-s.stopping:
+			return
+		default:
+		}
+		ctx := logging.WithRunID(s.ctx, logging.NewID())
+		ran, err := s.JobUsecase.RunNextJob(ctx)
 		if err != nil {
-			log.Printf("Error fetching users for notification check: %v", err)
+			slog.ErrorContext(ctx, "Error processing jobs", "error", err)
+			return
+		}
+		if !ran {
 			return
 		}
+	}
+}
 
-		nowInUTC := time.Now().UTC() 
+// ScheduleJobWorkers starts n workers that poll for queued jobs on spec, by
+// default every 5 seconds. Jobs are leased,
+// so workers on every replica can share the queue, and a job whose worker
+// dies is retried once its lease expires. Several workers keep a long job,
+// such as the nightly check, from holding up reminders.
+func (s *ConsistencyScheduler) ScheduleJobWorkers(n int, spec string) error {
+	for i := 0; i < n; i++ {
+		job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(s.processJobs))
+		if _, err := s.Cron.AddJob(spec, job); err != nil {
+			return fmt.Errorf("scheduling job worker: %w", err)
+		}
+	}
+	slog.Info("Job workers scheduled.", "workers", n, "spec", spec)
+	return nil
+}
 
-		for _, user := range users {
-			if user.NotificationTime == "" || user.Timezone == "" {
-				log.Printf("User %s (ID: %s) has incomplete notification settings, skipping reminder.", user.Email, user.ID.Hex())
-				continue
-			}
+// ScheduleOutboxWorker delivers queued notifications and retries failed ones
+// on spec, by default every 30 seconds. cron.SkipIfStillRunning keeps a slow
+// batch from overlapping the next tick.
+func (s *ConsistencyScheduler) ScheduleOutboxWorker(spec string) error {
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(s.leaderOnly("process notification outbox", func(ctx context.Context) {
+		if err := s.NotificationUsecase.ProcessOutbox(ctx); err != nil {
+			slog.ErrorContext(ctx, "Error processing notification outbox", "error", err)
+		}
+	})))
+	if _, err := s.Cron.AddJob(spec, job); err != nil {
+		return fmt.Errorf("scheduling notification outbox worker: %w", err)
+	}
+	slog.Info("Notification outbox worker scheduled.", "spec", spec)
+	return nil
+}
 
-			loc, err := time.LoadLocation(user.Timezone)
-			if err != nil {
-				log.Printf("Invalid timezone '%s' for user %s (ID: %s): %v. Skipping reminder.", user.Timezone, user.Email, user.ID.Hex(), err)
-				continue
-			}
-			nowInUserLocalTime := nowInUTC.In(loc)
-			userPreferredTime := nowInUserLocalTime.Format("15:04") 
-			if user.NotificationTime == userPreferredTime {
-				log.Printf("It's %s in user's (%s) timezone (%s). Attempting to send notification.",
-					userPreferredTime, user.Email, user.Timezone)
-				err := s.ConsistencyUsecase.SendConsistencyReminder(context.Background(), user.ID.Hex())
-				if err != nil {
-					log.Printf("Error sending reminder to user %s: %v", user.ID.Hex(), err)
-				}
-			}
+// ScheduleDigests ticks on spec, by default every minute, and queues the
+// weekly and monthly progress digests that fell due since the previous tick.
+func (s *ConsistencyScheduler) ScheduleDigests(spec string) error {
+	_, err := s.Cron.AddFunc(spec, s.leaderOnly("dispatch progress digests", func(ctx context.Context) {
+		if err := s.ReportUsecase.DispatchDigests(ctx, time.Now().UTC()); err != nil {
+			slog.ErrorContext(ctx, "Error dispatching progress digests", "error", err)
 		}
-	})
+	}))
 	if err != nil {
-		log.Fatalf("Error scheduling hourly notification reminder: %v", err)
+		return fmt.Errorf("scheduling progress digest dispatch: %w", err)
 	}
-	log.Println("Hourly notification reminder check scheduled.")
-}
\ No newline at end of file
+	slog.Info("Progress digest dispatch scheduled.", "spec", spec)
+	return nil
+}
+
+// ScheduleSyncRetries looks on spec, by default every 5 minutes, for today's
+// days whose platform fetch failed and re-checks those due under each day's
+// own backoff.
+func (s *ConsistencyScheduler) ScheduleSyncRetries(spec string) error {
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DefaultLogger)).Then(cron.FuncJob(s.leaderOnly("retry unsynced days", func(ctx context.Context) {
+		if err := s.ConsistencyUsecase.RetryUnsyncedDays(ctx, time.Now().UTC()); err != nil {
+			slog.ErrorContext(ctx, "Error retrying unsynced consistency checks", "error", err)
+		}
+	})))
+	if _, err := s.Cron.AddJob(spec, job); err != nil {
+		return fmt.Errorf("scheduling consistency sync retries: %w", err)
+	}
+	slog.Info("Consistency sync retries scheduled.", "spec", spec)
+	return nil
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Repositories"
+)
+
+// LeaderElector keeps a lease so that only one replica runs scheduled jobs.
+// Every replica competes for the lease; the holder renews it every TTL/3 and,
+// if it dies, another replica takes over once the TTL runs out.
+type LeaderElector struct {
+	leases repositories.LeaseRepository
+	name   string
+	holder string
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	lastErr error              // last failure to reach the lease store
+	lease   *domain.Lease      // nil while another replica leads
+	cancel  context.CancelFunc // ends the current term
+	ctx     context.Context    // cancelled when the current term ends
+}
+
+func NewLeaderElector(leases repositories.LeaseRepository, name string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		leases: leases,
+		name:   name,
+		holder: holderID(),
+		ttl:    ttl,
+	}
+}
+
+// holderID identifies this process among replicas, e.g. "web-1:42:9f3c1a2b".
+func holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Run competes for the lease until ctx is cancelled.
+func (e *LeaderElector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		e.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			e.step("stopping")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *LeaderElector) refresh(ctx context.Context) {
+	attemptCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+	lease, err := e.leases.Acquire(attemptCtx, e.name, e.holder, e.ttl)
+	e.mu.Lock()
+	e.lastErr = nil
+	if err != nil && err != domain.ErrLeaseHeld {
+		e.lastErr = err
+	}
+	e.mu.Unlock()
+	switch {
+	case err == nil:
+		e.lead(ctx, lease)
+	case err == domain.ErrLeaseHeld:
+		e.step("lease held by another replica")
+	default:
+		// Keep leading on a transient error until our own copy of the lease
+		// runs out; by then another replica may have taken over.
+		slog.ErrorContext(ctx, "Error renewing lease", "lease", e.name, "error", err)
+		e.mu.Lock()
+		expired := e.lease != nil && !time.Now().Before(e.lease.ExpiresAt)
+		e.mu.Unlock()
+		if expired {
+			e.step("lease expired")
+		}
+	}
+}
+
+func (e *LeaderElector) lead(parent context.Context, lease *domain.Lease) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease != nil && e.lease.Token == lease.Token {
+		e.lease = lease
+		return
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.lease = lease
+	e.ctx, e.cancel = context.WithCancel(parent)
+	slog.InfoContext(parent, "Acquired lease", "lease", e.name, "holder", e.holder, "token", lease.Token)
+}
+
+func (e *LeaderElector) step(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease == nil {
+		return
+	}
+	e.cancel()
+	slog.Warn("Lost lease", "lease", e.name, "token", e.lease.Token, "reason", reason)
+	e.lease, e.ctx, e.cancel = nil, nil, nil
+}
+
+// Lead reports whether this replica may run a job right now. It re-checks the
+// lease's fencing token in the store, so a replica that stalled past its TTL
+// does not run a job its successor is already running. The returned context
+// is cancelled when the term ends.
+func (e *LeaderElector) Lead(ctx context.Context) (context.Context, bool) {
+	e.mu.Lock()
+	lease, termCtx := e.lease, e.ctx
+	e.mu.Unlock()
+	if lease == nil || !time.Now().Before(lease.ExpiresAt) {
+		return nil, false
+	}
+	if err := e.leases.Check(ctx, e.name, e.holder, lease.Token); err != nil {
+		if err != domain.ErrLeaseHeld {
+			slog.ErrorContext(ctx, "Error checking lease", "lease", e.name, "error", err)
+		}
+		return nil, false
+	}
+	return termCtx, true
+}
+
+// Status describes this replica's part in the election for readiness probes.
+// It returns an error while the lease store cannot be reached.
+func (e *LeaderElector) Status() (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lastErr != nil {
+		return "", fmt.Errorf("%s lease store unreachable: %w", e.name, e.lastErr)
+	}
+	if e.lease != nil {
+		return fmt.Sprintf("leader as %s (token %d)", e.holder, e.lease.Token), nil
+	}
+	return fmt.Sprintf("standby as %s", e.holder), nil
+}
+
+// Release gives up the lease so another replica can take over immediately.
+func (e *LeaderElector) Release(ctx context.Context) {
+	if err := e.leases.Release(ctx, e.name, e.holder); err != nil {
+		slog.ErrorContext(ctx, "Error releasing lease", "lease", e.name, "error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AchievementRepository interface {
+	Unlock(ctx context.Context, achievement *domain.Achievement) error
+	MarkAnnounced(ctx context.Context, id primitive.ObjectID) error
+	GetUserAchievements(ctx context.Context, userID primitive.ObjectID) ([]domain.Achievement, error)
+}
+
+type achievementRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAchievementRepository(db *mongo.Database) AchievementRepository {
+	return &achievementRepository{
+		collection: db.Collection("achievements"),
+	}
+}
+
+// Unlock records the achievement. It returns domain.ErrAchievementAlreadyUnlocked
+// if the user already has it.
+func (r *achievementRepository) Unlock(ctx context.Context, achievement *domain.Achievement) error {
+	achievement.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, achievement)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAchievementAlreadyUnlocked
+	}
+	return err
+}
+
+func (r *achievementRepository) MarkAnnounced(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"announced": true}})
+	return err
+}
+
+func (r *achievementRepository) GetUserAchievements(ctx context.Context, userID primitive.ObjectID) ([]domain.Achievement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "unlockedAt", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var achievements []domain.Achievement
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
@@ -18,6 +18,7 @@ type ConsistencyRepository interface {
 	GetDailyConsistency(ctx context.Context, userID primitive.ObjectID, date time.Time) (*domain.DailyConsistency, error)
 	GetConsistencyHistory(ctx context.Context, filter domain.ConsistencyFilter) ([]domain.DailyConsistency, error)
 	GetStreaks(ctx context.Context, userID primitive.ObjectID) (*domain.StreakInfo, error)
+	GetDaysDueForSync(ctx context.Context, date, now time.Time) ([]domain.DailyConsistency, error)
 }
 
 type consistencyRepository struct {
@@ -40,6 +41,9 @@ func (r *consistencyRepository) SaveDailyConsistency(ctx context.Context, consis
 	update := bson.M{"$set": bson.M{
 		"platformActivities": consistency.PlatformActivities,
 		"overallConsistent":  consistency.OverallConsistent,
+		"syncStatus":         consistency.SyncStatus,
+		"syncAttempts":       consistency.SyncAttempts,
+		"nextSyncAt":         consistency.NextSyncAt,
 		"updatedAt":          time.Now(),
 	}}
 	opts := options.Update().SetUpsert(true) 
@@ -49,6 +53,10 @@ func (r *consistencyRepository) SaveDailyConsistency(ctx context.Context, consis
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert inserted the day first; this one now updates it.
+		result, err = r.collection.UpdateOne(ctx, filter, update, opts)
+	}
 	if err != nil {
 		return err
 	}
@@ -108,104 +116,26 @@ func (r *consistencyRepository) GetStreaks(ctx context.Context, userID primitive
 	if err != nil {
 		return nil, err
 	}
+	return domain.ComputeStreaks(consistencies, domain.StreakDay(time.Now())), nil
+}
 
-	streakInfo := &domain.StreakInfo{}
-	if len(consistencies) == 0 {
-		return streakInfo, nil
-	}
-
-
-	var consistentDays []time.Time
-	for _, dc := range consistencies {
-		if dc.OverallConsistent {
-			consistentDays = append(consistentDays, dc.Date)
-		}
-	}
-
-	if len(consistentDays) == 0 {
-		return streakInfo, nil 
-	}
-
-
-
-	var currentStreak int
-	var longestStreak int
-	var lastConsistentDay *time.Time
-
-	today := time.Now().UTC().Truncate(24 * time.Hour) 
-	yesterday := today.AddDate(0, 0, -1) 
-	for i := len(consistentDays) - 1; i >= 0; i-- {
-		day := consistentDays[i]
-		if lastConsistentDay == nil {
-			if day.Equal(today) || day.Equal(yesterday) {
-				currentStreak = 1
-				lastConsistentDay = &day
-			} else {
-			
-				currentStreak = 0
-				break
-			}
-		} else {
-			
-			if day.AddDate(0, 0, 1).Equal(*lastConsistentDay) {
-				currentStreak++
-				lastConsistentDay = &day 
-			} else {
-			
-				break
-			}
-		}
-	}
-
-
-	
-	if len(consistentDays) > 0 {
-		currentCount := 0
-		for i := 0; i < len(consistentDays); i++ {
-			if i == 0 || consistentDays[i].Equal(consistentDays[i-1].AddDate(0, 0, 1)) {
-				currentCount++
-			} else {
-				currentCount = 1 
-			}
-			if currentCount > longestStreak {
-				longestStreak = currentCount
-			}
-		}
+// GetDaysDueForSync returns the records for date that still have a failed or
+// pending platform and whose next retry is due by now.
+func (r *consistencyRepository) GetDaysDueForSync(ctx context.Context, date, now time.Time) ([]domain.DailyConsistency, error) {
+	filter := bson.M{
+		"date":       time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		"syncStatus": bson.M{"$in": bson.A{domain.SyncStatusFailed, domain.SyncStatusPending}},
+		"nextSyncAt": bson.M{"$lte": now},
 	}
-	if len(consistentDays) > 0 {
-		mostRecentConsistentDay := consistentDays[len(consistentDays)-1]
-		streakInfo.LastConsistentDay = &mostRecentConsistentDay
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	actualCurrentStreak := 0
-	if len(consistentDays) > 0 {
-		mostRecentConsistent := consistentDays[len(consistentDays)-1]
-		if mostRecentConsistent.Equal(today) {
-			actualCurrentStreak = 1
-			for i := len(consistentDays) - 2; i >= 0; i-- {
-				if consistentDays[i].Equal(consistentDays[i+1].AddDate(0, 0, -1)) {
-					actualCurrentStreak++
-				} else {
-					break
-				}
-			}
-		} else if mostRecentConsistent.Equal(yesterday) {
-			actualCurrentStreak = 1
-			for i := len(consistentDays) - 2; i >= 0; i-- {
-				if consistentDays[i].Equal(consistentDays[i+1].AddDate(0, 0, -1)) {
-					actualCurrentStreak++
-				} else {
-					break
-				}
-			}
-		} else {
-			actualCurrentStreak = 0 
-		}
+	var days []domain.DailyConsistency
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
 	}
-
-
-	streakInfo.CurrentStreak = actualCurrentStreak
-	streakInfo.LongestStreak = longestStreak
-
-	return streakInfo, nil
-}
\ No newline at end of file
+	return days, nil
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InboxRepository interface {
+	Add(ctx context.Context, item *domain.InboxItem) error
+	RemoveUnread(ctx context.Context, userID primitive.ObjectID, typePrefixes []string, since time.Time) (int64, error)
+	GetUserInbox(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.InboxItem, error)
+	CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	MarkRead(ctx context.Context, userID, itemID primitive.ObjectID) error
+	MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error)
+}
+
+type inboxRepository struct {
+	collection *mongo.Collection
+}
+
+func NewInboxRepository(db *mongo.Database) InboxRepository {
+	return &inboxRepository{
+		collection: db.Collection("inbox"),
+	}
+}
+
+// Add inserts the item as unread. It returns domain.ErrInboxItemDuplicate if
+// an item with the same dedup key already exists.
+func (r *inboxRepository) Add(ctx context.Context, item *domain.InboxItem) error {
+	item.ID = primitive.NewObjectID()
+	item.Read = false
+	item.ReadAt = nil
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+	_, err := r.collection.InsertOne(ctx, item)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrInboxItemDuplicate
+	}
+	return err
+}
+
+// RemoveUnread deletes the user's unread items created since the given time
+// whose type starts with one of the prefixes, such as reminders that no longer
+// apply once the user has been consistent.
+func (r *inboxRepository) RemoveUnread(ctx context.Context, userID primitive.ObjectID, typePrefixes []string, since time.Time) (int64, error) {
+	if len(typePrefixes) == 0 {
+		return 0, nil
+	}
+	patterns := make([]string, len(typePrefixes))
+	for i, prefix := range typePrefixes {
+		patterns[i] = regexp.QuoteMeta(prefix)
+	}
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"userId":    userID,
+		"read":      false,
+		"type":      bson.M{"$regex": "^(" + strings.Join(patterns, "|") + ")"},
+		"createdAt": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *inboxRepository) GetUserInbox(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.InboxItem, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	items := []domain.InboxItem{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *inboxRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"userId": userID, "read": false})
+}
+
+// MarkRead marks one of the user's items read. It returns domain.ErrInboxItemNotFound
+// if the item does not exist or belongs to someone else; marking an already
+// read item is not an error.
+func (r *inboxRepository) MarkRead(ctx context.Context, userID, itemID primitive.ObjectID) error {
+	update := bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": itemID, "userId": userID, "read": false}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Either already read (keep the original readAt) or not the user's item.
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": itemID, "userId": userID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return domain.ErrInboxItemNotFound
+		}
+	}
+	return nil
+}
+
+func (r *inboxRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"userId": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
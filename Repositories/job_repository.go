@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *domain.Job) error
+	// ClaimNext leases the oldest due job: a queued or failed job whose RunAt
+	// has passed, or a running job whose worker let the lease expire.
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*domain.Job, error)
+	ExtendLease(ctx context.Context, job *domain.Job, until time.Time) error
+	// Finish writes back the job's outcome and releases its lease.
+	Finish(ctx context.Context, job *domain.Job) error
+	Requeue(ctx context.Context, id primitive.ObjectID) (*domain.Job, error)
+	GetJob(ctx context.Context, id primitive.ObjectID) (*domain.Job, error)
+	ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error)
+	// CountByStatus counts jobs in each status that has any.
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+
+	StartRun(ctx context.Context, run *domain.JobRun) error
+	FinishRun(ctx context.Context, run *domain.JobRun) error
+	// AbandonRuns closes runs of the job left open by a worker that died.
+	AbandonRuns(ctx context.Context, jobID primitive.ObjectID) error
+	ListRuns(ctx context.Context, filter domain.JobFilter) ([]domain.JobRun, error)
+}
+
+type jobRepository struct {
+	jobs *mongo.Collection
+	runs *mongo.Collection
+}
+
+func NewJobRepository(db *mongo.Database) JobRepository {
+	return &jobRepository{
+		jobs: db.Collection("jobs"),
+		runs: db.Collection("job_runs"),
+	}
+}
+
+// Enqueue inserts the job as queued. It returns domain.ErrJobDuplicate if a
+// job with the same dedup key already exists.
+func (r *jobRepository) Enqueue(ctx context.Context, job *domain.Job) error {
+	now := time.Now()
+	job.ID = primitive.NewObjectID()
+	job.Status = domain.JobStatusQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+
+	_, err := r.jobs.InsertOne(ctx, job)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrJobDuplicate
+	}
+	return err
+}
+
+func (r *jobRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*domain.Job, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{
+			"status": bson.M{"$in": bson.A{domain.JobStatusQueued, domain.JobStatusFailed}},
+			"runAt":  bson.M{"$lte": now},
+		},
+		bson.M{
+			"status":     domain.JobStatusRunning,
+			"leaseUntil": bson.M{"$lte": now},
+		},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     domain.JobStatusRunning,
+			"leaseId":    primitive.NewObjectID().Hex(),
+			"leaseUntil": now.Add(lease),
+			"updatedAt":  now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "runAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job domain.Job
+	err := r.jobs.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ExtendLease returns domain.ErrLeaseHeld if another worker has taken the job over.
+func (r *jobRepository) ExtendLease(ctx context.Context, job *domain.Job, until time.Time) error {
+	result, err := r.jobs.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "leaseId": job.LeaseID},
+		bson.M{"$set": bson.M{"leaseUntil": until}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrLeaseHeld
+	}
+	job.LeaseUntil = until
+	return nil
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *domain.Job) error {
+	job.UpdatedAt = time.Now()
+	result, err := r.jobs.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "leaseId": job.LeaseID},
+		bson.M{
+			"$set": bson.M{
+				"status":    job.Status,
+				"attempts":  job.Attempts,
+				"runAt":     job.RunAt,
+				"lastError": job.LastError,
+				"updatedAt": job.UpdatedAt,
+			},
+			"$unset": bson.M{"leaseId": "", "leaseUntil": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrLeaseHeld
+	}
+	return nil
+}
+
+// Requeue resets a failed or dead job to run again now with fresh attempts.
+func (r *jobRepository) Requeue(ctx context.Context, id primitive.ObjectID) (*domain.Job, error) {
+	now := time.Now()
+	var job domain.Job
+	err := r.jobs.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": bson.A{domain.JobStatusFailed, domain.JobStatusDead}}},
+		bson.M{"$set": bson.M{
+			"status":    domain.JobStatusQueued,
+			"attempts":  0,
+			"runAt":     now,
+			"updatedAt": now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		if _, err := r.GetJob(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrJobNotRequeueable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) GetJob(ctx context.Context, id primitive.ObjectID) (*domain.Job, error) {
+	var job domain.Job
+	err := r.jobs.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *jobRepository) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updatedAt", Value: -1}}).
+		SetSkip(filter.Offset).
+		SetLimit(filter.Limit)
+
+	var jobs []domain.Job
+	cursor, err := r.jobs.Find(ctx, jobQuery(filter, "_id"), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	cursor, err := r.jobs.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(groups))
+	for _, g := range groups {
+		counts[g.Status] = g.Count
+	}
+	return counts, nil
+}
+
+func (r *jobRepository) StartRun(ctx context.Context, run *domain.JobRun) error {
+	run.ID = primitive.NewObjectID()
+	run.Status = domain.JobRunRunning
+	_, err := r.runs.InsertOne(ctx, run)
+	return err
+}
+
+func (r *jobRepository) FinishRun(ctx context.Context, run *domain.JobRun) error {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Duration = finishedAt.Sub(run.StartedAt)
+	_, err := r.runs.UpdateOne(ctx,
+		bson.M{"_id": run.ID},
+		bson.M{"$set": bson.M{
+			"status":     run.Status,
+			"error":      run.Error,
+			"result":     run.Result,
+			"finishedAt": run.FinishedAt,
+			"duration":   run.Duration,
+		}},
+	)
+	return err
+}
+
+func (r *jobRepository) AbandonRuns(ctx context.Context, jobID primitive.ObjectID) error {
+	_, err := r.runs.UpdateMany(ctx,
+		bson.M{"jobId": jobID, "status": domain.JobRunRunning},
+		bson.M{"$set": bson.M{
+			"status":     domain.JobRunAbandoned,
+			"error":      "worker stopped before the run finished",
+			"finishedAt": time.Now(),
+		}},
+	)
+	return err
+}
+
+func (r *jobRepository) ListRuns(ctx context.Context, filter domain.JobFilter) ([]domain.JobRun, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "startedAt", Value: -1}}).
+		SetSkip(filter.Offset).
+		SetLimit(filter.Limit)
+
+	var runs []domain.JobRun
+	cursor, err := r.runs.Find(ctx, jobQuery(filter, "jobId"), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// jobQuery turns a JobFilter into a query; idField names the field that holds
+// the job's ID in the collection being queried.
+func jobQuery(filter domain.JobFilter, idField string) bson.M {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if !filter.JobID.IsZero() {
+		query[idField] = filter.JobID
+	}
+	return query
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeaseRepository stores named leases, one document per name. Expiry is
+// judged by each caller's clock, so TTLs should comfortably exceed the clock
+// skew between replicas.
+type LeaseRepository interface {
+	// Acquire renews the lease if holder already has it, or takes it over if
+	// it is free or expired. It returns domain.ErrLeaseHeld otherwise.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error)
+	// Check returns domain.ErrLeaseHeld unless holder still has the lease under
+	// token and it has not expired.
+	Check(ctx context.Context, name, holder string, token int64) error
+	// Release expires the lease early so another process can take over.
+	Release(ctx context.Context, name, holder string) error
+	// Get returns the lease as last written, or nil if it was never taken.
+	Get(ctx context.Context, name string) (*domain.Lease, error)
+}
+
+type leaseRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLeaseRepository(db *mongo.Database) LeaseRepository {
+	return &leaseRepository{
+		collection: db.Collection("leases"),
+	}
+}
+
+func (r *leaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error) {
+	now := time.Now().UTC()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var lease domain.Lease
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name, "holder": holder},
+		bson.M{"$set": bson.M{"expiresAt": now.Add(ttl)}},
+		opts,
+	).Decode(&lease)
+	if err == nil {
+		return &lease, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Take over a free or expired lease. If the lease is live the filter misses
+	// and the upsert collides with the existing _id.
+	err = r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": name, "expiresAt": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{"holder": holder, "acquiredAt": now, "expiresAt": now.Add(ttl)},
+			"$inc": bson.M{"token": 1},
+		},
+		opts.SetUpsert(true),
+	).Decode(&lease)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, domain.ErrLeaseHeld
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (r *leaseRepository) Check(ctx context.Context, name, holder string, token int64) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"_id":       name,
+		"holder":    holder,
+		"token":     token,
+		"expiresAt": bson.M{"$gt": time.Now().UTC()},
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrLeaseHeld
+	}
+	return nil
+}
+
+func (r *leaseRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder},
+		bson.M{"$set": bson.M{"expiresAt": time.Time{}}},
+	)
+	return err
+}
+
+func (r *leaseRepository) Get(ctx context.Context, name string) (*domain.Lease, error) {
+	var lease domain.Lease
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The memory* repositories keep their data in process for --mode=dev, so the
+// service runs without MongoDB. They mirror the Mongo repositories' filters,
+// ordering and errors but not their TTL indexes, and lose everything on exit.
+
+// memoryCopy returns a deep copy of v made by a BSON round trip, so the store
+// never shares slices or maps with callers and values come back the way Mongo
+// returns them: omitempty fields dropped, times in UTC to the millisecond.
+func memoryCopy[T any](v T) (T, error) {
+	var out T
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = bson.Unmarshal(data, &out)
+	return out, err
+}
+
+func memoryCopies[T any](values []T) ([]T, error) {
+	var out []T
+	for _, v := range values {
+		c, err := memoryCopy(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// memorySet applies update to stored the way {$set: update} would: fields
+// that update omits keep their stored values.
+func memorySet[T any](stored *T, update interface{}) error {
+	data, err := bson.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if data, err = bson.Marshal(update); err != nil {
+		return err
+	}
+	fields := bson.M{}
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for k, v := range fields {
+		doc[k] = v
+	}
+	if data, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*stored = out
+	return nil
+}
+
+// memoryUnset clears the named fields of stored the way {$unset: fields} would.
+func memoryUnset[T any](stored *T, fields bson.M) error {
+	data, err := bson.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for k := range fields {
+		delete(doc, k)
+	}
+	if data, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+	var out T
+	if err := bson.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*stored = out
+	return nil
+}
+
+// memoryPage applies a Mongo-style skip and limit, where limit 0 means no limit.
+func memoryPage[T any](items []T, offset, limit int64) []T {
+	if offset >= int64(len(items)) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAchievementRepository struct {
+	mu           sync.Mutex
+	achievements []domain.Achievement
+}
+
+// NewMemoryAchievementRepository returns an empty in-process AchievementRepository.
+func NewMemoryAchievementRepository() AchievementRepository {
+	return &memoryAchievementRepository{}
+}
+
+func (r *memoryAchievementRepository) Unlock(ctx context.Context, achievement *domain.Achievement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.achievements {
+		if a.UserID == achievement.UserID && a.AchievementID == achievement.AchievementID {
+			return domain.ErrAchievementAlreadyUnlocked
+		}
+	}
+	achievement.ID = primitive.NewObjectID()
+	stored, err := memoryCopy(*achievement)
+	if err != nil {
+		return err
+	}
+	r.achievements = append(r.achievements, stored)
+	return nil
+}
+
+func (r *memoryAchievementRepository) MarkAnnounced(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.achievements {
+		if r.achievements[i].ID == id {
+			r.achievements[i].Announced = true
+		}
+	}
+	return nil
+}
+
+func (r *memoryAchievementRepository) GetUserAchievements(ctx context.Context, userID primitive.ObjectID) ([]domain.Achievement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var achievements []domain.Achievement
+	for _, a := range r.achievements {
+		if a.UserID == userID {
+			achievements = append(achievements, a)
+		}
+	}
+	sort.SliceStable(achievements, func(i, j int) bool {
+		return achievements[i].UnlockedAt.Before(achievements[j].UnlockedAt)
+	})
+	return memoryCopies(achievements)
+}
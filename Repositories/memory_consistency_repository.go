@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryConsistencyRepository struct {
+	mu   sync.Mutex
+	days []domain.DailyConsistency
+}
+
+// NewMemoryConsistencyRepository returns an empty in-process ConsistencyRepository.
+func NewMemoryConsistencyRepository() ConsistencyRepository {
+	return &memoryConsistencyRepository{}
+}
+
+func (r *memoryConsistencyRepository) find(userID primitive.ObjectID, date time.Time) *domain.DailyConsistency {
+	for i := range r.days {
+		if r.days[i].UserID == userID && r.days[i].Date.Equal(date) {
+			return &r.days[i]
+		}
+	}
+	return nil
+}
+
+func (r *memoryConsistencyRepository) SaveDailyConsistency(ctx context.Context, consistency *domain.DailyConsistency) error {
+	consistency.Date = time.Date(consistency.Date.Year(), consistency.Date.Month(), consistency.Date.Day(), 0, 0, 0, 0, time.UTC)
+	if consistency.ID.IsZero() {
+		consistency.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(consistency.UserID, consistency.Date)
+	if stored == nil {
+		// Like the Mongo upsert, a new record holds only the filter and $set fields.
+		r.days = append(r.days, domain.DailyConsistency{
+			ID:     primitive.NewObjectID(),
+			UserID: consistency.UserID,
+			Date:   consistency.Date,
+		})
+		stored = &r.days[len(r.days)-1]
+		consistency.ID = stored.ID
+	}
+	stored.PlatformActivities = consistency.PlatformActivities
+	stored.OverallConsistent = consistency.OverallConsistent
+	stored.SyncStatus = consistency.SyncStatus
+	stored.SyncAttempts = consistency.SyncAttempts
+	stored.NextSyncAt = consistency.NextSyncAt
+	stored.UpdatedAt = time.Now()
+	copied, err := memoryCopy(*stored)
+	if err != nil {
+		return err
+	}
+	*stored = copied
+	return nil
+}
+
+func (r *memoryConsistencyRepository) GetDailyConsistency(ctx context.Context, userID primitive.ObjectID, date time.Time) (*domain.DailyConsistency, error) {
+	normalizedDate := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID, normalizedDate)
+	if stored == nil {
+		return nil, domain.ErrConsistencyNotFound
+	}
+	consistency, err := memoryCopy(*stored)
+	return &consistency, err
+}
+
+func (r *memoryConsistencyRepository) GetConsistencyHistory(ctx context.Context, filter domain.ConsistencyFilter) ([]domain.DailyConsistency, error) {
+	var startOfDay, endOfDay time.Time
+	if filter.StartDate != nil {
+		startOfDay = time.Date(filter.StartDate.Year(), filter.StartDate.Month(), filter.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if filter.EndDate != nil {
+		endOfDay = time.Date(filter.EndDate.Year(), filter.EndDate.Month(), filter.EndDate.Day(), 23, 59, 59, 999999999, time.UTC)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var consistencies []domain.DailyConsistency
+	for _, dc := range r.days {
+		if dc.UserID != filter.UserID ||
+			(filter.StartDate != nil && dc.Date.Before(startOfDay)) ||
+			(filter.EndDate != nil && dc.Date.After(endOfDay)) {
+			continue
+		}
+		consistencies = append(consistencies, dc)
+	}
+	sort.SliceStable(consistencies, func(i, j int) bool {
+		return consistencies[i].Date.Before(consistencies[j].Date)
+	})
+	return memoryCopies(consistencies)
+}
+
+func (r *memoryConsistencyRepository) GetStreaks(ctx context.Context, userID primitive.ObjectID) (*domain.StreakInfo, error) {
+	consistencies, err := r.GetConsistencyHistory(ctx, domain.ConsistencyFilter{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.ComputeStreaks(consistencies, domain.StreakDay(time.Now())), nil
+}
+
+func (r *memoryConsistencyRepository) GetDaysDueForSync(ctx context.Context, date, now time.Time) ([]domain.DailyConsistency, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var days []domain.DailyConsistency
+	for _, dc := range r.days {
+		if dc.Date.Equal(day) &&
+			(dc.SyncStatus == domain.SyncStatusFailed || dc.SyncStatus == domain.SyncStatusPending) &&
+			!dc.NextSyncAt.IsZero() && !dc.NextSyncAt.After(now) {
+			days = append(days, dc)
+		}
+	}
+	return memoryCopies(days)
+}
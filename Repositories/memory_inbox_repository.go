@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryInboxRepository struct {
+	mu    sync.Mutex
+	items []domain.InboxItem
+}
+
+// NewMemoryInboxRepository returns an empty in-process InboxRepository.
+func NewMemoryInboxRepository() InboxRepository {
+	return &memoryInboxRepository{}
+}
+
+func (r *memoryInboxRepository) Add(ctx context.Context, item *domain.InboxItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if item.DedupKey != "" {
+		for _, existing := range r.items {
+			if existing.DedupKey == item.DedupKey {
+				return domain.ErrInboxItemDuplicate
+			}
+		}
+	}
+
+	item.ID = primitive.NewObjectID()
+	item.Read = false
+	item.ReadAt = nil
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+	stored, err := memoryCopy(*item)
+	if err != nil {
+		return err
+	}
+	r.items = append(r.items, stored)
+	return nil
+}
+
+func (r *memoryInboxRepository) RemoveUnread(ctx context.Context, userID primitive.ObjectID, typePrefixes []string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	kept := r.items[:0]
+	for _, item := range r.items {
+		if item.UserID == userID && !item.Read && !item.CreatedAt.Before(since) && hasAnyPrefix(item.Type, typePrefixes) {
+			count++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	r.items = kept
+	return count, nil
+}
+
+func (r *memoryInboxRepository) GetUserInbox(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.InboxItem, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var items []domain.InboxItem
+	for _, item := range r.items {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	page, err := memoryCopies(memoryPage(items, offset, limit))
+	if page == nil && err == nil {
+		page = []domain.InboxItem{}
+	}
+	return page, err
+}
+
+func (r *memoryInboxRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, item := range r.items {
+		if item.UserID == userID && !item.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryInboxRepository) MarkRead(ctx context.Context, userID, itemID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.items {
+		item := &r.items[i]
+		if item.ID != itemID || item.UserID != userID {
+			continue
+		}
+		if !item.Read {
+			readAt := time.Now().UTC().Truncate(time.Millisecond)
+			item.Read, item.ReadAt = true, &readAt
+		}
+		return nil
+	}
+	return domain.ErrInboxItemNotFound
+}
+
+func (r *memoryInboxRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	readAt := time.Now().UTC().Truncate(time.Millisecond)
+	var count int64
+	for i := range r.items {
+		item := &r.items[i]
+		if item.UserID == userID && !item.Read {
+			at := readAt
+			item.Read, item.ReadAt = true, &at
+			count++
+		}
+	}
+	return count, nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
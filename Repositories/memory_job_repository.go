@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryJobRepository struct {
+	mu   sync.Mutex
+	jobs []domain.Job
+	runs []domain.JobRun
+}
+
+// NewMemoryJobRepository returns an empty in-process JobRepository. Finished
+// jobs and runs are kept until the process exits.
+func NewMemoryJobRepository() JobRepository {
+	return &memoryJobRepository{}
+}
+
+func (r *memoryJobRepository) find(id primitive.ObjectID) *domain.Job {
+	for i := range r.jobs {
+		if r.jobs[i].ID == id {
+			return &r.jobs[i]
+		}
+	}
+	return nil
+}
+
+// save normalizes a job changed in place to what Mongo would have stored.
+func (r *memoryJobRepository) save(stored *domain.Job) (*domain.Job, error) {
+	copied, err := memoryCopy(*stored)
+	if err != nil {
+		return nil, err
+	}
+	*stored = copied
+	job, err := memoryCopy(copied)
+	return &job, err
+}
+
+func (r *memoryJobRepository) Enqueue(ctx context.Context, job *domain.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.DedupKey != "" {
+		for _, existing := range r.jobs {
+			if existing.DedupKey == job.DedupKey {
+				return domain.ErrJobDuplicate
+			}
+		}
+	}
+
+	now := time.Now()
+	job.ID = primitive.NewObjectID()
+	job.Status = domain.JobStatusQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	stored, err := memoryCopy(*job)
+	if err != nil {
+		return err
+	}
+	r.jobs = append(r.jobs, stored)
+	return nil
+}
+
+func (r *memoryJobRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var next *domain.Job
+	for i := range r.jobs {
+		job := &r.jobs[i]
+		due := false
+		switch job.Status {
+		case domain.JobStatusQueued, domain.JobStatusFailed:
+			due = !job.RunAt.After(now)
+		case domain.JobStatusRunning:
+			due = !job.LeaseUntil.IsZero() && !job.LeaseUntil.After(now)
+		}
+		if due && (next == nil || job.RunAt.Before(next.RunAt)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = domain.JobStatusRunning
+	next.LeaseID = primitive.NewObjectID().Hex()
+	next.LeaseUntil = now.Add(lease)
+	next.UpdatedAt = now
+	next.Attempts++
+	return r.save(next)
+}
+
+func (r *memoryJobRepository) ExtendLease(ctx context.Context, job *domain.Job, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(job.ID)
+	if stored == nil || stored.LeaseID != job.LeaseID {
+		return domain.ErrLeaseHeld
+	}
+	stored.LeaseUntil = until
+	if _, err := r.save(stored); err != nil {
+		return err
+	}
+	job.LeaseUntil = until
+	return nil
+}
+
+func (r *memoryJobRepository) Finish(ctx context.Context, job *domain.Job) error {
+	job.UpdatedAt = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(job.ID)
+	if stored == nil || stored.LeaseID != job.LeaseID {
+		return domain.ErrLeaseHeld
+	}
+	stored.Status = job.Status
+	stored.Attempts = job.Attempts
+	stored.RunAt = job.RunAt
+	stored.LastError = job.LastError
+	stored.UpdatedAt = job.UpdatedAt
+	stored.LeaseID, stored.LeaseUntil = "", time.Time{}
+	_, err := r.save(stored)
+	return err
+}
+
+func (r *memoryJobRepository) Requeue(ctx context.Context, id primitive.ObjectID) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(id)
+	if stored == nil {
+		return nil, domain.ErrJobNotFound
+	}
+	if stored.Status != domain.JobStatusFailed && stored.Status != domain.JobStatusDead {
+		return nil, domain.ErrJobNotRequeueable
+	}
+	now := time.Now()
+	stored.Status = domain.JobStatusQueued
+	stored.Attempts = 0
+	stored.RunAt = now
+	stored.UpdatedAt = now
+	return r.save(stored)
+}
+
+func (r *memoryJobRepository) GetJob(ctx context.Context, id primitive.ObjectID) (*domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(id)
+	if stored == nil {
+		return nil, domain.ErrJobNotFound
+	}
+	job, err := memoryCopy(*stored)
+	return &job, err
+}
+
+// matchesJobFilter is jobQuery for the in-memory store; jobID is the job's ID
+// in the collection being queried.
+func matchesJobFilter(filter domain.JobFilter, jobType, status string, jobID primitive.ObjectID) bool {
+	return (filter.Type == "" || filter.Type == jobType) &&
+		(filter.Status == "" || filter.Status == status) &&
+		(filter.JobID.IsZero() || filter.JobID == jobID)
+}
+
+func (r *memoryJobRepository) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []domain.Job
+	for _, job := range r.jobs {
+		if matchesJobFilter(filter, job.Type, job.Status, job.ID) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+	})
+	return memoryCopies(memoryPage(jobs, filter.Offset, filter.Limit))
+}
+
+func (r *memoryJobRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, job := range r.jobs {
+		counts[job.Status]++
+	}
+	return counts, nil
+}
+
+func (r *memoryJobRepository) StartRun(ctx context.Context, run *domain.JobRun) error {
+	run.ID = primitive.NewObjectID()
+	run.Status = domain.JobRunRunning
+	stored, err := memoryCopy(*run)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs = append(r.runs, stored)
+	return nil
+}
+
+func (r *memoryJobRepository) FinishRun(ctx context.Context, run *domain.JobRun) error {
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Duration = finishedAt.Sub(run.StartedAt)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.runs {
+		stored := &r.runs[i]
+		if stored.ID != run.ID {
+			continue
+		}
+		stored.Status = run.Status
+		stored.Error = run.Error
+		stored.Result = run.Result
+		stored.FinishedAt = run.FinishedAt
+		stored.Duration = run.Duration
+		copied, err := memoryCopy(*stored)
+		if err != nil {
+			return err
+		}
+		*stored = copied
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) AbandonRuns(ctx context.Context, jobID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	finishedAt := time.Now().UTC().Truncate(time.Millisecond)
+	for i := range r.runs {
+		run := &r.runs[i]
+		if run.JobID == jobID && run.Status == domain.JobRunRunning {
+			at := finishedAt
+			run.Status = domain.JobRunAbandoned
+			run.Error = "worker stopped before the run finished"
+			run.FinishedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *memoryJobRepository) ListRuns(ctx context.Context, filter domain.JobFilter) ([]domain.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var runs []domain.JobRun
+	for _, run := range r.runs {
+		if matchesJobFilter(filter, run.Type, run.Status, run.JobID) {
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+	return memoryCopies(memoryPage(runs, filter.Offset, filter.Limit))
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+)
+
+type memoryLeaseRepository struct {
+	mu     sync.Mutex
+	leases map[string]domain.Lease
+}
+
+// NewMemoryLeaseRepository returns an in-process LeaseRepository. It only
+// arbitrates between holders in the same process.
+func NewMemoryLeaseRepository() LeaseRepository {
+	return &memoryLeaseRepository{leases: make(map[string]domain.Lease)}
+}
+
+func (r *memoryLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*domain.Lease, error) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, exists := r.leases[name]
+	switch {
+	case exists && lease.Holder == holder:
+		lease.ExpiresAt = now.Add(ttl)
+	case !exists || !lease.ExpiresAt.After(now):
+		lease = domain.Lease{
+			Name:       name,
+			Holder:     holder,
+			Token:      lease.Token + 1,
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(ttl),
+		}
+	default:
+		return nil, domain.ErrLeaseHeld
+	}
+	r.leases[name] = lease
+	return &lease, nil
+}
+
+func (r *memoryLeaseRepository) Check(ctx context.Context, name, holder string, token int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, exists := r.leases[name]
+	if !exists || lease.Holder != holder || lease.Token != token || !lease.ExpiresAt.After(time.Now().UTC()) {
+		return domain.ErrLeaseHeld
+	}
+	return nil
+}
+
+func (r *memoryLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, exists := r.leases[name]; exists && lease.Holder == holder {
+		lease.ExpiresAt = time.Time{}
+		r.leases[name] = lease
+	}
+	return nil
+}
+
+func (r *memoryLeaseRepository) Get(ctx context.Context, name string) (*domain.Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, exists := r.leases[name]
+	if !exists {
+		return nil, nil
+	}
+	return &lease, nil
+}
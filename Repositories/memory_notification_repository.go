@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryNotificationRepository struct {
+	mu      sync.Mutex
+	records []domain.NotificationRecord
+}
+
+// NewMemoryNotificationRepository returns an empty in-process NotificationRepository.
+func NewMemoryNotificationRepository() NotificationRepository {
+	return &memoryNotificationRepository{}
+}
+
+func (r *memoryNotificationRepository) Enqueue(ctx context.Context, record *domain.NotificationRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.records {
+		if existing.DedupKey == record.DedupKey {
+			return domain.ErrNotificationDuplicate
+		}
+	}
+
+	now := time.Now()
+	record.ID = primitive.NewObjectID()
+	record.Status = domain.NotificationStatusPending
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	if record.NextAttemptAt.IsZero() {
+		record.NextAttemptAt = now
+	}
+	stored, err := memoryCopy(*record)
+	if err != nil {
+		return err
+	}
+	r.records = append(r.records, stored)
+	return nil
+}
+
+// unleased reports whether no worker holds a live lease on the record.
+func unleased(record *domain.NotificationRecord, now time.Time) bool {
+	return record.LeaseUntil.IsZero() || !record.LeaseUntil.After(now)
+}
+
+func (r *memoryNotificationRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]domain.NotificationRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []*domain.NotificationRecord
+	for i := range r.records {
+		record := &r.records[i]
+		if record.Status == domain.NotificationStatusPending && !record.NextAttemptAt.After(now) && unleased(record, now) {
+			due = append(due, record)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	due = memoryPage(due, 0, limit)
+
+	leaseID := primitive.NewObjectID().Hex()
+	leaseUntil := now.Add(lease).UTC().Truncate(time.Millisecond)
+	var records []domain.NotificationRecord
+	for _, record := range due {
+		record.LeaseID, record.LeaseUntil = leaseID, leaseUntil
+		claimed, err := memoryCopy(*record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, claimed)
+	}
+	return records, nil
+}
+
+func (r *memoryNotificationRepository) SaveDeliveryState(ctx context.Context, record *domain.NotificationRecord) error {
+	record.UpdatedAt = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.records {
+		stored := &r.records[i]
+		if stored.ID != record.ID || stored.LeaseID != record.LeaseID {
+			continue
+		}
+		stored.Channels = record.Channels
+		stored.Status = record.Status
+		stored.Attempts = record.Attempts
+		stored.NextAttemptAt = record.NextAttemptAt
+		stored.UpdatedAt = record.UpdatedAt
+		stored.LeaseID, stored.LeaseUntil = "", time.Time{}
+		copied, err := memoryCopy(*stored)
+		if err != nil {
+			return err
+		}
+		*stored = copied
+	}
+	return nil
+}
+
+func (r *memoryNotificationRepository) CancelPending(ctx context.Context, userID primitive.ObjectID, typePrefixes []string) (int64, error) {
+	if len(typePrefixes) == 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for i := range r.records {
+		record := &r.records[i]
+		if record.UserID != userID || record.Status != domain.NotificationStatusPending || !unleased(record, now) {
+			continue
+		}
+		for _, prefix := range typePrefixes {
+			if strings.HasPrefix(record.Type, prefix) {
+				record.Status = domain.NotificationStatusCancelled
+				record.UpdatedAt = now.UTC().Truncate(time.Millisecond)
+				count++
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryNotificationRepository) GetUserNotifications(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.NotificationRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []domain.NotificationRecord
+	for _, record := range r.records {
+		if record.UserID == userID {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return memoryCopies(memoryPage(records, offset, limit))
+}
+
+func (r *memoryNotificationRepository) CountPending(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, record := range r.records {
+		if record.Status == domain.NotificationStatusPending {
+			count++
+		}
+	}
+	return count, nil
+}
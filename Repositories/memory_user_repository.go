@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	mu    sync.Mutex
+	users []domain.User
+}
+
+// NewMemoryUserRepository returns an empty in-process UserRepository.
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{}
+}
+
+func (r *memoryUserRepository) find(id primitive.ObjectID) *domain.User {
+	for i := range r.users {
+		if r.users[i].ID == id {
+			return &r.users[i]
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	user.ID = primitive.NewObjectID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+	user.LeetCodeLastTotalSolved = 0
+	user.LeetCodeLastCheckDate = time.Time{}
+	user.LeetCodeLastHardSolved = 0
+
+	stored, err := memoryCopy(*user)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return domain.ErrEmailAlreadyExists
+		}
+	}
+	r.users = append(r.users, stored)
+	return nil
+}
+
+func (r *memoryUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(objID)
+	if stored == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	user, err := memoryCopy(*stored)
+	return &user, err
+}
+
+func (r *memoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.users {
+		if stored.Email == email {
+			user, err := memoryCopy(stored)
+			return &user, err
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *memoryUserRepository) UpdateUser(ctx context.Context, userID primitive.ObjectID, update *domain.UserUpdate) error {
+	set, unset := userUpdateFields(update)
+	set["updatedAt"] = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID)
+	if stored == nil {
+		return domain.ErrUserNotFound
+	}
+	if err := memorySet(stored, set); err != nil {
+		return err
+	}
+	return memoryUnset(stored, unset)
+}
+
+func (r *memoryUserRepository) RegisterDevice(ctx context.Context, userID primitive.ObjectID, device domain.Device) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID)
+	if stored == nil {
+		return domain.ErrUserNotFound
+	}
+	known := false
+	for i := range stored.Devices {
+		existing := &stored.Devices[i]
+		if existing.Token != device.Token {
+			continue
+		}
+		existing.LastSeen = device.LastSeen
+		if device.Platform != "" {
+			existing.Platform = device.Platform
+		}
+		if device.AppVersion != "" {
+			existing.AppVersion = device.AppVersion
+		}
+		known = true
+	}
+	if !known {
+		stored.Devices = append(stored.Devices, device)
+	}
+	stored.UpdatedAt = time.Now()
+	copied, err := memoryCopy(*stored)
+	if err != nil {
+		return err
+	}
+	*stored = copied
+	return nil
+}
+
+func (r *memoryUserRepository) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return memoryCopies(r.users)
+}
+
+func (r *memoryUserRepository) UpdateUserLeetCodeStats(ctx context.Context, userID primitive.ObjectID, totalSolved, hardSolved int, lastCheckDate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID)
+	if stored == nil {
+		return nil
+	}
+	return memorySet(stored, bson.M{
+		"leetcodeLastTotalSolved": totalSolved,
+		"leetcodeLastHardSolved":  hardSolved,
+		"leetcodeLastCheckDate":   lastCheckDate,
+		"updatedAt":               time.Now(),
+	})
+}
+
+func (r *memoryUserRepository) RemoveDevice(ctx context.Context, userID primitive.ObjectID, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID)
+	if stored == nil || !removeTokens(stored, map[string]bool{token: true}) {
+		return domain.ErrDeviceNotFound
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) RemoveDeviceTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	remove := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		remove[token] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		removeTokens(&r.users[i], remove)
+	}
+	return nil
+}
+
+// removeTokens pulls the tokens from the user's devices and legacy tokens and
+// reports whether the user held any of them.
+func removeTokens(user *domain.User, remove map[string]bool) bool {
+	var devices []domain.Device
+	for _, d := range user.Devices {
+		if !remove[d.Token] {
+			devices = append(devices, d)
+		}
+	}
+	var legacy []string
+	for _, t := range user.FCMTokens {
+		if !remove[t] {
+			legacy = append(legacy, t)
+		}
+	}
+	if len(devices) == len(user.Devices) && len(legacy) == len(user.FCMTokens) {
+		return false
+	}
+	user.Devices, user.FCMTokens = devices, legacy
+	user.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	return true
+}
+
+func (r *memoryUserRepository) GetUsersDueForReminder(ctx context.Context, now time.Time) ([]domain.User, error) {
+	return r.usersDueBy("nextReminderAt", now)
+}
+
+func (r *memoryUserRepository) ClaimReminder(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error) {
+	return r.claimSchedule(userID, "nextReminderAt", dueAt, next)
+}
+
+func (r *memoryUserRepository) GetUsersDueForEscalation(ctx context.Context, now time.Time) ([]domain.User, error) {
+	return r.usersDueBy("nextEscalationAt", now)
+}
+
+func (r *memoryUserRepository) ClaimEscalation(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error) {
+	return r.claimSchedule(userID, "nextEscalationAt", dueAt, next)
+}
+
+func (r *memoryUserRepository) GetUsersDueForDigest(ctx context.Context, period string, now time.Time) ([]domain.User, error) {
+	field, err := digestScheduleField(period)
+	if err != nil {
+		return nil, err
+	}
+	return r.usersDueBy(field, now)
+}
+
+func (r *memoryUserRepository) ClaimDigest(ctx context.Context, userID primitive.ObjectID, period string, dueAt, next time.Time) (bool, error) {
+	field, err := digestScheduleField(period)
+	if err != nil {
+		return false, err
+	}
+	return r.claimSchedule(userID, field, dueAt, next)
+}
+
+// scheduleTime returns the user's schedule field named as in the users
+// collection.
+func scheduleTime(user *domain.User, field string) *time.Time {
+	switch field {
+	case "nextReminderAt":
+		return &user.NextReminderAt
+	case "nextEscalationAt":
+		return &user.NextEscalationAt
+	case "nextWeeklyDigestAt":
+		return &user.NextWeeklyDigestAt
+	case "nextMonthlyDigestAt":
+		return &user.NextMonthlyDigestAt
+	}
+	panic("unknown schedule field " + field)
+}
+
+func (r *memoryUserRepository) usersDueBy(field string, now time.Time) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []domain.User
+	for i := range r.users {
+		if at := scheduleTime(&r.users[i], field); at.IsZero() || !at.After(now) {
+			due = append(due, r.users[i])
+		}
+	}
+	return memoryCopies(due)
+}
+
+func (r *memoryUserRepository) claimSchedule(userID primitive.ObjectID, field string, dueAt, next time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.find(userID)
+	if stored == nil {
+		return false, nil
+	}
+	at := scheduleTime(stored, field)
+	if !at.Equal(dueAt) || at.Equal(next) {
+		return false, nil
+	}
+	return true, memorySet(stored, bson.M{field: next})
+}
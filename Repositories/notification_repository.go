@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository interface {
+	Enqueue(ctx context.Context, record *domain.NotificationRecord) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]domain.NotificationRecord, error)
+	SaveDeliveryState(ctx context.Context, record *domain.NotificationRecord) error
+	CancelPending(ctx context.Context, userID primitive.ObjectID, typePrefixes []string) (int64, error)
+	GetUserNotifications(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.NotificationRecord, error)
+	CountPending(ctx context.Context) (int64, error)
+}
+
+type notificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationRepository(db *mongo.Database) NotificationRepository {
+	return &notificationRepository{
+		collection: db.Collection("notifications"),
+	}
+}
+
+// Enqueue inserts the record as pending. It returns domain.ErrNotificationDuplicate
+// if a record with the same dedup key already exists.
+func (r *notificationRepository) Enqueue(ctx context.Context, record *domain.NotificationRecord) error {
+	now := time.Now()
+	record.ID = primitive.NewObjectID()
+	record.Status = domain.NotificationStatusPending
+	record.CreatedAt = now
+	record.UpdatedAt = now
+	if record.NextAttemptAt.IsZero() {
+		record.NextAttemptAt = now
+	}
+
+	_, err := r.collection.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrNotificationDuplicate
+	}
+	return err
+}
+
+// ClaimDue leases up to limit pending records whose next attempt is due, so
+// concurrent workers never deliver the same record at the same time.
+func (r *notificationRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int64) ([]domain.NotificationRecord, error) {
+	dueFilter := bson.M{
+		"status":        domain.NotificationStatusPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"leaseUntil": bson.M{"$exists": false}},
+			bson.M{"leaseUntil": bson.M{"$lte": now}},
+		},
+	}
+
+	cursor, err := r.collection.Find(ctx, dueFilter, options.Find().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetLimit(limit).
+		SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	ids := make([]primitive.ObjectID, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+
+	leaseID := primitive.NewObjectID().Hex()
+	dueFilter["_id"] = bson.M{"$in": ids}
+	_, err = r.collection.UpdateMany(ctx, dueFilter, bson.M{"$set": bson.M{
+		"leaseId":    leaseID,
+		"leaseUntil": now.Add(lease),
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []domain.NotificationRecord
+	cursor, err = r.collection.Find(ctx, bson.M{"leaseId": leaseID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SaveDeliveryState writes back the outcome of a delivery attempt and releases the lease.
+func (r *notificationRepository) SaveDeliveryState(ctx context.Context, record *domain.NotificationRecord) error {
+	record.UpdatedAt = time.Now()
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": record.ID, "leaseId": record.LeaseID},
+		bson.M{
+			"$set": bson.M{
+				"channels":      record.Channels,
+				"status":        record.Status,
+				"attempts":      record.Attempts,
+				"nextAttemptAt": record.NextAttemptAt,
+				"updatedAt":     record.UpdatedAt,
+			},
+			"$unset": bson.M{"leaseId": "", "leaseUntil": ""},
+		},
+	)
+	return err
+}
+
+// CancelPending cancels the user's undelivered notifications whose type starts
+// with any of typePrefixes. Records currently leased by a worker are left alone.
+func (r *notificationRepository) CancelPending(ctx context.Context, userID primitive.ObjectID, typePrefixes []string) (int64, error) {
+	if len(typePrefixes) == 0 {
+		return 0, nil
+	}
+	patterns := make([]string, len(typePrefixes))
+	for i, prefix := range typePrefixes {
+		patterns[i] = regexp.QuoteMeta(prefix)
+	}
+	now := time.Now()
+	filter := bson.M{
+		"userId": userID,
+		"status": domain.NotificationStatusPending,
+		"type":   bson.M{"$regex": "^(" + strings.Join(patterns, "|") + ")"},
+		"$or": bson.A{
+			bson.M{"leaseUntil": bson.M{"$exists": false}},
+			bson.M{"leaseUntil": bson.M{"$lte": now}},
+		},
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":    domain.NotificationStatusCancelled,
+		"updatedAt": now,
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *notificationRepository) GetUserNotifications(ctx context.Context, userID primitive.ObjectID, limit, offset int64) ([]domain.NotificationRecord, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(offset).
+		SetLimit(limit)
+
+	var records []domain.NotificationRecord
+	cursor, err := r.collection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// CountPending counts outbox records still waiting for delivery.
+func (r *notificationRepository) CountPending(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"status": domain.NotificationStatusPending})
+}
@@ -114,11 +114,19 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUserByID(ctx context.Context, id string) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
-	UpdateUser(ctx context.Context, user *domain.User) error
+	UpdateUser(ctx context.Context, userID primitive.ObjectID, update *domain.UserUpdate) error
+	RegisterDevice(ctx context.Context, userID primitive.ObjectID, device domain.Device) error
 	GetAllUsers(ctx context.Context) ([]domain.User, error)
 	
-	UpdateUserLeetCodeStats(ctx context.Context, userID primitive.ObjectID, totalSolved int, lastCheckDate time.Time) error
-	
+	UpdateUserLeetCodeStats(ctx context.Context, userID primitive.ObjectID, totalSolved, hardSolved int, lastCheckDate time.Time) error
+	GetUsersDueForReminder(ctx context.Context, now time.Time) ([]domain.User, error)
+	ClaimReminder(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error)
+	GetUsersDueForEscalation(ctx context.Context, now time.Time) ([]domain.User, error)
+	ClaimEscalation(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error)
+	GetUsersDueForDigest(ctx context.Context, period string, now time.Time) ([]domain.User, error)
+	ClaimDigest(ctx context.Context, userID primitive.ObjectID, period string, dueAt, next time.Time) (bool, error)
+	RemoveDevice(ctx context.Context, userID primitive.ObjectID, token string) error
+	RemoveDeviceTokens(ctx context.Context, tokens []string) error
 }
 
 type userRepository struct {
@@ -132,6 +140,7 @@ func NewUserRepository(db *mongo.Database) UserRepository {
 }
 
 
+// CreateUser returns domain.ErrEmailAlreadyExists if the email is taken.
 func (r *userRepository) CreateUser(ctx context.Context, user *domain.User) error {
 	user.ID = primitive.NewObjectID()
 	user.CreatedAt = time.Now()
@@ -140,8 +149,12 @@ func (r *userRepository) CreateUser(ctx context.Context, user *domain.User) erro
 	
 	user.LeetCodeLastTotalSolved = 0
 	user.LeetCodeLastCheckDate = time.Time{} 
+	user.LeetCodeLastHardSolved = 0
 
 	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrEmailAlreadyExists
+	}
 	return err
 }
 
@@ -171,14 +184,113 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*dom
 }
 
 
-func (r *userRepository) UpdateUser(ctx context.Context, user *domain.User) error {
-	user.UpdatedAt = time.Now()
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": user.ID},
-		bson.M{"$set": user}, 
+// UpdateUser writes only the fields set in update, leaving the schedule and
+// device fields that other writers maintain untouched.
+func (r *userRepository) UpdateUser(ctx context.Context, userID primitive.ObjectID, update *domain.UserUpdate) error {
+	set, unset := userUpdateFields(update)
+	set["updatedAt"] = time.Now()
+	doc := bson.M{"$set": set}
+	if len(unset) > 0 {
+		doc["$unset"] = unset
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, doc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+
+// userUpdateFields splits update into the fields to $set and the fields to
+// $unset. Empty values are unset, matching the omitempty tags on domain.User.
+func userUpdateFields(update *domain.UserUpdate) (set, unset bson.M) {
+	set, unset = bson.M{}, bson.M{}
+	str := func(field string, v *string) {
+		switch {
+		case v == nil:
+		case *v == "":
+			unset[field] = ""
+		default:
+			set[field] = *v
+		}
+	}
+	instant := func(field string, v *time.Time) {
+		switch {
+		case v == nil:
+		case v.IsZero():
+			unset[field] = ""
+		default:
+			set[field] = *v
+		}
+	}
+
+	str("username", update.Username)
+	str("notificationTime", update.NotificationTime)
+	str("timezone", update.Timezone)
+	instant("nextReminderAt", update.NextReminderAt)
+	instant("nextEscalationAt", update.NextEscalationAt)
+	instant("nextWeeklyDigestAt", update.NextWeeklyDigestAt)
+	instant("nextMonthlyDigestAt", update.NextMonthlyDigestAt)
+	if update.NotificationChannels != nil {
+		if len(*update.NotificationChannels) == 0 {
+			unset["notificationChannels"] = ""
+		} else {
+			set["notificationChannels"] = *update.NotificationChannels
+		}
+	}
+	str("telegramChatId", update.TelegramChatID)
+	str("webhookUrl", update.WebhookURL)
+	str("locale", update.Locale)
+	if update.PlatformUsernames != nil {
+		set["platformUsernames"] = update.PlatformUsernames
+	}
+	if update.NotificationPreferences != nil {
+		set["notificationPreferences"] = *update.NotificationPreferences
+	}
+	return set, unset
+}
+
+
+// RegisterDevice adds device to the user, or refreshes the stored record with
+// the same token: LastSeen always, Platform and AppVersion when they are set.
+func (r *userRepository) RegisterDevice(ctx context.Context, userID primitive.ObjectID, device domain.Device) error {
+	refresh := bson.M{"devices.$.lastSeen": device.LastSeen}
+	if device.Platform != "" {
+		refresh["devices.$.platform"] = device.Platform
+	}
+	if device.AppVersion != "" {
+		refresh["devices.$.appVersion"] = device.AppVersion
+	}
+	refresh["updatedAt"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "devices.token": device.Token},
+		bson.M{"$set": refresh},
 	)
-	return err
+	if err != nil || result.MatchedCount > 0 {
+		return err
+	}
+
+	// The token filter keeps a concurrent registration of the same device
+	// from adding it twice.
+	result, err = r.collection.UpdateOne(ctx,
+		bson.M{"_id": userID, "devices.token": bson.M{"$ne": device.Token}},
+		bson.M{
+			"$push": bson.M{"devices": device},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.GetUserByID(ctx, userID.Hex()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 
@@ -197,15 +309,160 @@ func (r *userRepository) GetAllUsers(ctx context.Context) ([]domain.User, error)
 }
 
 
-func (r *userRepository) UpdateUserLeetCodeStats(ctx context.Context, userID primitive.ObjectID, totalSolved int, lastCheckDate time.Time) error {
+func (r *userRepository) UpdateUserLeetCodeStats(ctx context.Context, userID primitive.ObjectID, totalSolved, hardSolved int, lastCheckDate time.Time) error {
 	filter := bson.M{"_id": userID}
 	update := bson.M{
 		"$set": bson.M{
 			"leetcodeLastTotalSolved": totalSolved,
+			"leetcodeLastHardSolved":  hardSolved,
 			"leetcodeLastCheckDate":   lastCheckDate,
 			"updatedAt":               time.Now(), 
 		},
 	}
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	return err
-}
\ No newline at end of file
+}
+
+
+// RemoveDevice unregisters a single device from the given user.
+func (r *userRepository) RemoveDevice(ctx context.Context, userID primitive.ObjectID, token string) error {
+	filter := bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{"devices.token": token},
+			bson.M{"fcmTokens": token},
+		},
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"devices":   bson.M{"token": token},
+			"fcmTokens": token,
+		},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrDeviceNotFound
+	}
+	return nil
+}
+
+
+// RemoveDeviceTokens drops the given tokens from every user that holds them.
+// It is used to prune tokens that FCM no longer accepts.
+func (r *userRepository) RemoveDeviceTokens(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"devices.token": bson.M{"$in": tokens}},
+			bson.M{"fcmTokens": bson.M{"$in": tokens}},
+		},
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"devices":   bson.M{"token": bson.M{"$in": tokens}},
+			"fcmTokens": bson.M{"$in": tokens},
+		},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+
+// GetUsersDueForReminder returns users whose next reminder is at or before now,
+// plus users that have never had a reminder scheduled.
+func (r *userRepository) GetUsersDueForReminder(ctx context.Context, now time.Time) ([]domain.User, error) {
+	return r.usersDueBy(ctx, "nextReminderAt", now)
+}
+
+
+// ClaimReminder advances the user's next reminder from dueAt to next, but only
+// if nobody else has advanced it first. It reports whether this caller won the
+// claim, so each due reminder is dispatched by at most one tick.
+func (r *userRepository) ClaimReminder(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error) {
+	return r.claimSchedule(ctx, userID, "nextReminderAt", dueAt, next)
+}
+
+
+// GetUsersDueForEscalation is GetUsersDueForReminder for streak-at-risk follow-ups.
+func (r *userRepository) GetUsersDueForEscalation(ctx context.Context, now time.Time) ([]domain.User, error) {
+	return r.usersDueBy(ctx, "nextEscalationAt", now)
+}
+
+
+// ClaimEscalation is ClaimReminder for streak-at-risk follow-ups.
+func (r *userRepository) ClaimEscalation(ctx context.Context, userID primitive.ObjectID, dueAt, next time.Time) (bool, error) {
+	return r.claimSchedule(ctx, userID, "nextEscalationAt", dueAt, next)
+}
+
+
+// GetUsersDueForDigest is GetUsersDueForReminder for weekly or monthly digests.
+func (r *userRepository) GetUsersDueForDigest(ctx context.Context, period string, now time.Time) ([]domain.User, error) {
+	field, err := digestScheduleField(period)
+	if err != nil {
+		return nil, err
+	}
+	return r.usersDueBy(ctx, field, now)
+}
+
+
+// ClaimDigest is ClaimReminder for weekly or monthly digests.
+func (r *userRepository) ClaimDigest(ctx context.Context, userID primitive.ObjectID, period string, dueAt, next time.Time) (bool, error) {
+	field, err := digestScheduleField(period)
+	if err != nil {
+		return false, err
+	}
+	return r.claimSchedule(ctx, userID, field, dueAt, next)
+}
+
+
+func digestScheduleField(period string) (string, error) {
+	switch period {
+	case domain.ReportPeriodWeekly:
+		return "nextWeeklyDigestAt", nil
+	case domain.ReportPeriodMonthly:
+		return "nextMonthlyDigestAt", nil
+	}
+	return "", domain.ErrInvalidReportPeriod
+}
+
+
+func (r *userRepository) usersDueBy(ctx context.Context, field string, now time.Time) ([]domain.User, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{field: bson.M{"$lte": now}},
+			bson.M{field: bson.M{"$exists": false}},
+		},
+	}
+	var users []domain.User
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+
+func (r *userRepository) claimSchedule(ctx context.Context, userID primitive.ObjectID, field string, dueAt, next time.Time) (bool, error) {
+	filter := bson.M{"_id": userID, field: dueAt}
+	if dueAt.IsZero() {
+		filter[field] = bson.M{"$exists": false}
+	}
+	update := bson.M{"$set": bson.M{field: next}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,221 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/i18n"
+	"consistent_1/Infrastructure/notifications"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AchievementUsecase interface {
+	// Evaluate unlocks every rule the user now satisfies and announces each
+	// unlock once. It returns the achievements unlocked by this call.
+	Evaluate(ctx context.Context, user *domain.User) ([]domain.Achievement, error)
+	GetAchievements(ctx context.Context, userID, locale string) ([]domain.AchievementStatus, error)
+}
+
+const achievementNotificationType = "achievement_unlocked"
+
+type achievementUsecase struct {
+	consistencyRepo     repositories.ConsistencyRepository
+	achievementRepo     repositories.AchievementRepository
+	notificationUsecase NotificationUsecase
+}
+
+func NewAchievementUsecase(
+	consistencyRepo repositories.ConsistencyRepository,
+	achievementRepo repositories.AchievementRepository,
+	notificationUsecase NotificationUsecase,
+) AchievementUsecase {
+	return &achievementUsecase{
+		consistencyRepo:     consistencyRepo,
+		achievementRepo:     achievementRepo,
+		notificationUsecase: notificationUsecase,
+	}
+}
+
+func (uc *achievementUsecase) Evaluate(ctx context.Context, user *domain.User) ([]domain.Achievement, error) {
+	ctx, span := tracing.Start(ctx, "AchievementUsecase.Evaluate")
+	defer span.End()
+	stats, err := uc.stats(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := uc.achievementRepo.GetUserAchievements(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load achievements for user %s: %w", user.ID.Hex(), err)
+	}
+	unlocked := make(map[string]bool, len(existing))
+	for _, a := range existing {
+		unlocked[a.AchievementID] = true
+	}
+
+	var newlyUnlocked []domain.Achievement
+	for _, rule := range domain.AchievementRules {
+		if unlocked[rule.ID] || !rule.Met(stats) {
+			continue
+		}
+		achievement := domain.Achievement{
+			UserID:        user.ID,
+			AchievementID: rule.ID,
+			UnlockedAt:    time.Now(),
+		}
+		if err := uc.achievementRepo.Unlock(ctx, &achievement); err != nil {
+			if err != domain.ErrAchievementAlreadyUnlocked {
+				slog.ErrorContext(ctx, "Failed to unlock achievement", "achievement_id", rule.ID, "user_id", user.ID.Hex(), "error", err)
+			}
+			continue
+		}
+		newlyUnlocked = append(newlyUnlocked, achievement)
+		existing = append(existing, achievement)
+	}
+
+	// Announce anything not yet announced, including unlocks whose announcement
+	// failed on an earlier run.
+	for _, achievement := range existing {
+		if !achievement.Announced {
+			uc.announce(ctx, user, achievement)
+		}
+	}
+	return newlyUnlocked, nil
+}
+
+func (uc *achievementUsecase) announce(ctx context.Context, user *domain.User, achievement domain.Achievement) {
+	rule, ok := findAchievementRule(achievement.AchievementID)
+	if !ok {
+		return
+	}
+	err := uc.notificationUsecase.Enqueue(ctx, user, notifications.Notification{
+		Type:  achievementNotificationType + ":" + rule.ID,
+		Kind:  domain.NotificationKindMilestone,
+		Title: i18n.T(user.Locale, "achievement.unlocked.title", map[string]string{"Title": achievementTitle(user.Locale, rule)}),
+		Body:  achievementDescription(user.Locale, rule),
+		Data: map[string]string{
+			"type":          achievementNotificationType,
+			"userId":        user.ID.Hex(),
+			"achievementId": rule.ID,
+		},
+	})
+	if err != nil && err != domain.ErrNotificationDuplicate && err != domain.ErrNotificationSuppressed {
+		slog.ErrorContext(ctx, "Failed to announce achievement", "achievement_id", rule.ID, "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+	if err := uc.achievementRepo.MarkAnnounced(ctx, achievement.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark achievement announced", "achievement_id", rule.ID, "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+func (uc *achievementUsecase) GetAchievements(ctx context.Context, userID, locale string) ([]domain.AchievementStatus, error) {
+	ctx, span := tracing.Start(ctx, "AchievementUsecase.GetAchievements")
+	defer span.End()
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	stats, err := uc.stats(ctx, objUserID)
+	if err != nil {
+		return nil, err
+	}
+	achievements, err := uc.achievementRepo.GetUserAchievements(ctx, objUserID)
+	if err != nil {
+		return nil, err
+	}
+	unlockedAt := make(map[string]time.Time, len(achievements))
+	for _, a := range achievements {
+		unlockedAt[a.AchievementID] = a.UnlockedAt
+	}
+
+	statuses := make([]domain.AchievementStatus, 0, len(domain.AchievementRules))
+	for _, rule := range domain.AchievementRules {
+		status := domain.AchievementStatus{AchievementRule: rule, Progress: stats[rule.Metric]}
+		status.Title = achievementTitle(locale, rule)
+		status.Description = achievementDescription(locale, rule)
+		if at, ok := unlockedAt[rule.ID]; ok {
+			status.Unlocked = true
+			status.UnlockedAt = &at
+		}
+		if status.Progress > rule.Threshold {
+			status.Progress = rule.Threshold
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// stats derives every rule metric from the user's full consistency history.
+func (uc *achievementUsecase) stats(ctx context.Context, userID primitive.ObjectID) (domain.AchievementStats, error) {
+	history, err := uc.consistencyRepo.GetConsistencyHistory(ctx, domain.ConsistencyFilter{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load consistency history: %w", err)
+	}
+	streaks, err := uc.consistencyRepo.GetStreaks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load streaks: %w", err)
+	}
+
+	stats := domain.AchievementStats{domain.MetricLongestStreak: streaks.LongestStreak}
+	run, longestRun := 0, 0
+	var prevDate time.Time
+	for _, dc := range history {
+		consistentPlatforms := 0
+		for _, activity := range dc.PlatformActivities {
+			stats[domain.MetricTotalSolved] += activity.ProblemsSolved
+			stats[domain.MetricHardSolved] += activity.HardSolved
+			if activity.IsConsistent {
+				consistentPlatforms++
+			}
+		}
+
+		// History is sorted by date, so a gap of more than a day breaks the run.
+		date := dc.Date.UTC()
+		switch {
+		case consistentPlatforms < 2:
+			run = 0
+		case run > 0 && date.Equal(prevDate.AddDate(0, 0, 1)):
+			run++
+		default:
+			run = 1
+		}
+		if run > longestRun {
+			longestRun = run
+		}
+		prevDate = date
+	}
+	stats[domain.MetricMultiPlatformStreak] = longestRun
+	return stats, nil
+}
+
+func findAchievementRule(id string) (domain.AchievementRule, bool) {
+	for _, rule := range domain.AchievementRules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return domain.AchievementRule{}, false
+}
+
+// achievementTitle and achievementDescription fall back to the rule's own
+// English copy for rules without a catalog entry.
+func achievementTitle(locale string, rule domain.AchievementRule) string {
+	key := "achievement." + rule.ID + ".title"
+	if text := i18n.T(locale, key, nil); text != key {
+		return text
+	}
+	return rule.Title
+}
+
+func achievementDescription(locale string, rule domain.AchievementRule) string {
+	key := "achievement." + rule.ID + ".description"
+	if text := i18n.T(locale, key, nil); text != key {
+		return text
+	}
+	return rule.Description
+}
@@ -6,12 +6,19 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log" 
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"consistent_1/Domain"
+	"consistent_1/Infrastructure/i18n"
+	"consistent_1/Infrastructure/metrics"
 	"consistent_1/Infrastructure/notifications"
+	"consistent_1/Infrastructure/tracing"
 	"consistent_1/Repositories"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -24,31 +31,80 @@ type ConsistencyUsecase interface {
 	GetConsistencyHistory(ctx context.Context, userID string, startDate, endDate *time.Time) ([]domain.DailyConsistency, error)
 	GetStreaks(ctx context.Context, userID string) (*domain.StreakInfo, error)
 	SendConsistencyReminder(ctx context.Context, userID string) error
-	TriggerDailyConsistencyCheck(ctx context.Context)
+	SendConsistencyReminders(ctx context.Context, users []domain.User) error
+	DispatchDueReminders(ctx context.Context, now time.Time) error
+	DispatchStreakEscalations(ctx context.Context, now time.Time) error
+	TriggerDailyConsistencyCheck(ctx context.Context) (*domain.ConsistencyRunSummary, error)
+	RetryUnsyncedDays(ctx context.Context, now time.Time) error
 }
 
+// EscalationPolicy configures streak-at-risk follow-ups. Each offset is how
+// long before the streak day ends at UTC midnight a follow-up goes out while
+// the day is still not consistent; an empty policy disables escalation.
+type EscalationPolicy struct {
+	Offsets []time.Duration
+}
+
+// CheckPolicy bounds TriggerDailyConsistencyCheck. Concurrency is how many
+// users are checked in parallel (at least one); UserTimeout caps each user's
+// check, including its platform API calls, and zero means no cap.
+type CheckPolicy struct {
+	Concurrency int
+	UserTimeout time.Duration
+}
+
+// Notification types that only make sense while today is not yet consistent.
+const (
+	reminderNotificationType    = "consistency_reminder"
+	escalationNotificationType  = "streak_at_risk"
+)
+
+const (
+	syncRetryBaseBackoff = 5 * time.Minute
+	syncRetryMaxBackoff  = 2 * time.Hour
+)
+
 type consistencyUsecase struct {
 	userRepo        repositories.UserRepository
 	consistencyRepo repositories.ConsistencyRepository
 	platformUsecase PlatformUsecase
-	fcmService      notifications.FCMService
+	notificationUsecase NotificationUsecase
+	achievementUsecase  AchievementUsecase
+	escalation          EscalationPolicy
+	checkPolicy         CheckPolicy
 }
 func NewConsistencyUsecase(
 	userRepo repositories.UserRepository,
 	consistencyRepo repositories.ConsistencyRepository,
 	platformUsecase PlatformUsecase,
-	fcmService notifications.FCMService,
+	notificationUsecase NotificationUsecase,
+	achievementUsecase AchievementUsecase,
+	escalation EscalationPolicy,
+	checkPolicy CheckPolicy,
 ) ConsistencyUsecase {
+	// Largest offset first, so stage 1 is the earliest and mildest follow-up.
+	offsets := append([]time.Duration(nil), escalation.Offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	escalation.Offsets = offsets
+	if checkPolicy.Concurrency < 1 {
+		checkPolicy.Concurrency = 1
+	}
+
 	return &consistencyUsecase{
 		userRepo:        userRepo,
 		consistencyRepo: consistencyRepo,
 		platformUsecase: platformUsecase,
-		fcmService:      fcmService,
+		notificationUsecase: notificationUsecase,
+		achievementUsecase:  achievementUsecase,
+		escalation:          escalation,
+		checkPolicy:         checkPolicy,
 	}
 }
 
 
 func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID string) (*domain.DailyConsistency, error) {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.CheckDailyConsistency")
+	defer span.End()
 	objUserID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
@@ -61,27 +117,34 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 
 	todayUTC := time.Now().UTC().Truncate(24 * time.Hour) 
 
+	dailyConsistency, err := uc.consistencyRepo.GetDailyConsistency(ctx, objUserID, todayUTC)
+	if err != nil && err != domain.ErrConsistencyNotFound {
+		return nil, fmt.Errorf("database error getting daily consistency: %w", err)
+	}
+
 	var platformActivities []domain.PlatformActivity
 	overallConsistent := false
 	if leetcodeUsername, ok := user.PlatformUsernames["leetcode"]; ok && leetcodeUsername != "" {
 		leetcodeCurrentActivity, err := uc.platformUsecase.FetchLeetCodeActivity(ctx, leetcodeUsername, todayUTC)
-		if err != nil {
-			log.Printf("Error fetching LeetCode activity for user %s (%s): %v", userID, leetcodeUsername, err) // Keep error log
-			platformActivities = append(platformActivities, domain.PlatformActivity{
-				Platform:       "leetcode",
-				Username:       leetcodeUsername,
-				Date:           todayUTC,
-				IsConsistent:   false,
-				ProblemsSolved: 0,
-			})
+		if errors.Is(err, domain.ErrPlatformDisabled) {
+			// Turned off on this server; the handle counts again once re-enabled.
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Error fetching LeetCode activity", "user_id", userID, "handle", leetcodeUsername, "error", err)
+			activity := unsyncedActivity(dailyConsistency, "leetcode", leetcodeUsername, todayUTC, err)
+			platformActivities = append(platformActivities, activity)
+			if activity.IsConsistent {
+				overallConsistent = true
+			}
 		} else {
 			problemsSolvedTodayLeetCode := 0
+			hardSolvedTodayLeetCode := 0
 			isLeetCodeConsistent := false
 
 			
 			if user.LeetCodeLastCheckDate.IsZero() && user.LeetCodeLastTotalSolved == 0 {
 				if leetcodeCurrentActivity.ProblemsSolved > 0 {
 					problemsSolvedTodayLeetCode = leetcodeCurrentActivity.ProblemsSolved 
+					hardSolvedTodayLeetCode = leetcodeCurrentActivity.HardSolved
 					isLeetCodeConsistent = true
 				} else {
 					problemsSolvedTodayLeetCode = 0
@@ -90,6 +153,7 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 			
 			} else if user.LeetCodeLastCheckDate.Before(todayUTC) {
 				problemsSolvedTodayLeetCode = leetcodeCurrentActivity.ProblemsSolved - user.LeetCodeLastTotalSolved
+				hardSolvedTodayLeetCode = leetcodeCurrentActivity.HardSolved - user.LeetCodeLastHardSolved
 				if problemsSolvedTodayLeetCode > 0 {
 					isLeetCodeConsistent = true
 				}
@@ -97,6 +161,7 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 			} else if user.LeetCodeLastCheckDate.Equal(todayUTC) {
 				if leetcodeCurrentActivity.ProblemsSolved > user.LeetCodeLastTotalSolved {
 					problemsSolvedTodayLeetCode = leetcodeCurrentActivity.ProblemsSolved - user.LeetCodeLastTotalSolved
+					hardSolvedTodayLeetCode = leetcodeCurrentActivity.HardSolved - user.LeetCodeLastHardSolved
 					isLeetCodeConsistent = problemsSolvedTodayLeetCode > 0
 				} else {
 					existingDailyCons, getErr := uc.consistencyRepo.GetDailyConsistency(ctx, objUserID, todayUTC)
@@ -104,18 +169,22 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 						activityForPlatform := existingDailyCons.GetPlatformActivity("leetcode")
 						isLeetCodeConsistent = activityForPlatform.IsConsistent
 						problemsSolvedTodayLeetCode = activityForPlatform.ProblemsSolved
+						hardSolvedTodayLeetCode = activityForPlatform.HardSolved
 					} else {
 						isLeetCodeConsistent = false
 						problemsSolvedTodayLeetCode = 0
 						if getErr != nil && getErr != domain.ErrConsistencyNotFound {
-							log.Printf("Warning: Error retrieving existing LeetCode consistency for user %s on %s: %v", userID, todayUTC.Format(time.RFC3339), getErr) // Keep warning log
+							slog.WarnContext(ctx, "Error retrieving existing LeetCode consistency", "user_id", userID, "date", todayUTC.Format("2006-01-02"), "error", getErr)
 						}
 					}
 				}
 			}
-			err = uc.userRepo.UpdateUserLeetCodeStats(ctx, objUserID, leetcodeCurrentActivity.ProblemsSolved, todayUTC)
+			if hardSolvedTodayLeetCode < 0 {
+				hardSolvedTodayLeetCode = 0
+			}
+			err = uc.userRepo.UpdateUserLeetCodeStats(ctx, objUserID, leetcodeCurrentActivity.ProblemsSolved, leetcodeCurrentActivity.HardSolved, todayUTC)
 			if err != nil {
-				log.Printf("Warning: Failed to update LeetCode stats for user %s (%s): %v", userID, leetcodeUsername, err) // Keep warning log
+				slog.WarnContext(ctx, "Failed to update LeetCode stats", "user_id", userID, "handle", leetcodeUsername, "error", err)
 			}
 
 			platformActivities = append(platformActivities, domain.PlatformActivity{
@@ -124,6 +193,8 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 				Date:           todayUTC,
 				IsConsistent:   isLeetCodeConsistent,
 				ProblemsSolved: problemsSolvedTodayLeetCode,
+				HardSolved:     hardSolvedTodayLeetCode,
+				SyncStatus:     domain.SyncStatusOK,
 			})
 			if isLeetCodeConsistent {
 				overallConsistent = true
@@ -132,27 +203,23 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 	}
 	if codeforcesUsername, ok := user.PlatformUsernames["codeforces"]; ok && codeforcesUsername != "" {
 		codeforcesActivity, err := uc.platformUsecase.FetchCodeforcesActivity(ctx, codeforcesUsername, todayUTC)
-		if err != nil {
-			log.Printf("Error fetching Codeforces activity for user %s (%s): %v", userID, codeforcesUsername, err) // Keep error log
-			platformActivities = append(platformActivities, domain.PlatformActivity{
-				Platform:       "codeforces",
-				Username:       codeforcesUsername,
-				Date:           todayUTC,
-				IsConsistent:   false,
-				ProblemsSolved: 0,
-			})
+		if errors.Is(err, domain.ErrPlatformDisabled) {
+			// Turned off on this server; the handle counts again once re-enabled.
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Error fetching Codeforces activity", "user_id", userID, "handle", codeforcesUsername, "error", err)
+			activity := unsyncedActivity(dailyConsistency, "codeforces", codeforcesUsername, todayUTC, err)
+			platformActivities = append(platformActivities, activity)
+			if activity.IsConsistent {
+				overallConsistent = true
+			}
 		} else {
+			codeforcesActivity.SyncStatus = domain.SyncStatusOK
 			platformActivities = append(platformActivities, codeforcesActivity)
 			if codeforcesActivity.IsConsistent {
 				overallConsistent = true
 			}
 		}
 	}
-	dailyConsistency, err := uc.consistencyRepo.GetDailyConsistency(ctx, objUserID, todayUTC)
-	if err != nil && err != domain.ErrConsistencyNotFound {
-		return nil, fmt.Errorf("database error getting daily consistency: %w", err)
-	}
-
 	if dailyConsistency == nil {
 		dailyConsistency = &domain.DailyConsistency{
 			UserID:             objUserID,
@@ -168,14 +235,35 @@ func (uc *consistencyUsecase) CheckDailyConsistency(ctx context.Context, userID
 		dailyConsistency.UpdatedAt = time.Now()
 	}
 
+	dailyConsistency.SettleSyncStatus()
+	if dailyConsistency.SyncStatus == domain.SyncStatusOK {
+		dailyConsistency.SyncAttempts = 0
+		dailyConsistency.NextSyncAt = time.Time{}
+	} else {
+		dailyConsistency.SyncAttempts++
+		dailyConsistency.NextSyncAt = time.Now().Add(syncRetryBackoff(dailyConsistency.SyncAttempts))
+	}
+
 	
 	if err := uc.consistencyRepo.SaveDailyConsistency(ctx, dailyConsistency); err != nil {
 		return nil, fmt.Errorf("failed to save daily consistency for user %s: %w", userID, err)
 	}
 
+	if overallConsistent {
+		if err := uc.notificationUsecase.CancelPending(ctx, objUserID, reminderNotificationType, escalationNotificationType); err != nil {
+			slog.WarnContext(ctx, "Failed to cancel pending reminders", "user_id", userID, "error", err)
+		}
+	}
+
+	if _, err := uc.achievementUsecase.Evaluate(ctx, user); err != nil {
+		slog.WarnContext(ctx, "Failed to evaluate achievements", "user_id", userID, "error", err)
+	}
+
 	return dailyConsistency, nil
 }
 func (uc *consistencyUsecase) GetDailyConsistency(ctx context.Context, userID string, date time.Time) (*domain.DailyConsistency, error) {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.GetDailyConsistency")
+	defer span.End()
 	objUserID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
@@ -183,6 +271,8 @@ func (uc *consistencyUsecase) GetDailyConsistency(ctx context.Context, userID st
 	return uc.consistencyRepo.GetDailyConsistency(ctx, objUserID, date.Truncate(24*time.Hour)) // Ensure date is truncated for consistent lookup
 }
 func (uc *consistencyUsecase) GetConsistencyHistory(ctx context.Context, userID string, startDate, endDate *time.Time) ([]domain.DailyConsistency, error) {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.GetConsistencyHistory")
+	defer span.End()
 	objUserID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
@@ -195,6 +285,8 @@ func (uc *consistencyUsecase) GetConsistencyHistory(ctx context.Context, userID
 	return uc.consistencyRepo.GetConsistencyHistory(ctx, filter)
 }
 func (uc *consistencyUsecase) GetStreaks(ctx context.Context, userID string) (*domain.StreakInfo, error) {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.GetStreaks")
+	defer span.End()
 	objUserID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
@@ -202,50 +294,419 @@ func (uc *consistencyUsecase) GetStreaks(ctx context.Context, userID string) (*d
 	return uc.consistencyRepo.GetStreaks(ctx, objUserID)
 }
 func (uc *consistencyUsecase) SendConsistencyReminder(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.SendConsistencyReminder")
+	defer span.End()
 	user, err := uc.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to find user for reminder: %w", err)
 	}
+	return uc.SendConsistencyReminders(ctx, []domain.User{*user})
+}
 
-	todayUTC := time.Now().UTC().Truncate(24 * time.Hour)
-	dailyConsistency, err := uc.consistencyRepo.GetDailyConsistency(ctx, user.ID, todayUTC)
-	if err != nil && err != domain.ErrConsistencyNotFound {
-		return fmt.Errorf("error checking daily consistency for reminder: %w", err)
+// SendConsistencyReminders queues a reminder for each given user who has not
+// been consistent today. The outbox drops any user already reminded on their
+// current local day, so repeated calls never double-notify.
+func (uc *consistencyUsecase) SendConsistencyReminders(ctx context.Context, users []domain.User) error {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.SendConsistencyReminders")
+	defer span.End()
+	day := domain.StreakDay(time.Now())
+	for i := range users {
+		uc.queueReminder(ctx, &users[i], day)
 	}
-	if dailyConsistency == nil || !dailyConsistency.OverallConsistent {
-		if len(user.FCMTokens) == 0 {
-			return nil
-		}
+	return nil
+}
 
-		title := "Consistify Reminder! ⏰"
-		body := fmt.Sprintf("Hey %s, you haven't solved today's challenge yet! Let's keep your streak alive 💪.", user.Username)
-		data := map[string]string{"type": "consistency_reminder", "userId": userID}
-		for _, token := range user.FCMTokens {
-			err := uc.fcmService.SendNotification(ctx, token, title, body, data)
-			if err != nil {
-				log.Printf("Failed to send FCM notification to token %s for user %s: %v", token, userID, err) // Keep error log
-				
-			}
+// queueReminder queues a reminder about the given streak day, unless the user
+// has already been consistent on it.
+func (uc *consistencyUsecase) queueReminder(ctx context.Context, user *domain.User, day time.Time) {
+	notification, err := uc.reminderNotification(ctx, user, day)
+	if err != nil {
+		slog.WarnContext(ctx, "Skipping reminder", "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+	if notification == nil {
+		return
+	}
+	err = uc.notificationUsecase.Enqueue(ctx, user, *notification)
+	if err != nil && err != domain.ErrNotificationDuplicate && err != domain.ErrNotificationSuppressed {
+		slog.ErrorContext(ctx, "Failed to queue reminder", "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+// DispatchDueReminders sends every reminder that fell due at or before now and
+// schedules each user's next one. A reminder is claimed before it is sent, so
+// overlapping or delayed ticks neither skip it nor deliver it twice.
+func (uc *consistencyUsecase) DispatchDueReminders(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.DispatchDueReminders")
+	defer span.End()
+	users, err := uc.userRepo.GetUsersDueForReminder(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to fetch users due for reminder: %w", err)
+	}
+
+	var dueUsers []domain.User
+	for _, user := range users {
+		next, err := user.NextReminderAfter(now)
+		if err != nil {
+			slog.WarnContext(ctx, "Invalid notification settings, parking reminders", "user_id", user.ID.Hex(), "notification_time", user.NotificationTime, "timezone", user.Timezone, "error", err)
+			next = domain.ScheduleParked
+		}
+		claimed, err := uc.userRepo.ClaimReminder(ctx, user.ID, user.NextReminderAt, next)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim reminder", "user_id", user.ID.Hex(), "error", err)
+			continue
 		}
+		if !claimed || user.NextReminderAt.IsZero() || next == domain.ScheduleParked {
+			// Either another tick got here first, or this is the user's first
+			// schedule and nothing was due yet.
+			continue
+		}
+		if isStaleSchedule(user, user.NextReminderAt, now) {
+			slog.InfoContext(ctx, "Dropping stale reminder", "user_id", user.ID.Hex(), "due_at", user.NextReminderAt.Format(time.RFC3339))
+			continue
+		}
+		dueUsers = append(dueUsers, user)
+	}
+
+	if len(dueUsers) == 0 {
 		return nil
 	}
+	slog.InfoContext(ctx, "Sending consistency reminders", "users", len(dueUsers))
+	for i := range dueUsers {
+		// Judge the streak day the reminder fell due in, even if this tick
+		// runs after UTC midnight.
+		uc.queueReminder(ctx, &dueUsers[i], domain.StreakDay(dueUsers[i].NextReminderAt))
+	}
+	return nil
+}
+
+// isStaleSchedule reports whether the user's local day has rolled over since
+// dueAt; a "solve today's problem" nudge is meaningless by then.
+func isStaleSchedule(user domain.User, dueAt, now time.Time) bool {
+	return user.LocalDate(dueAt) != user.LocalDate(now)
+}
+
+// DispatchStreakEscalations sends the streak-at-risk follow-ups that fell due
+// at or before now to users whose day is still not consistent, claiming each
+// one first exactly as DispatchDueReminders does.
+func (uc *consistencyUsecase) DispatchStreakEscalations(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.DispatchStreakEscalations")
+	defer span.End()
+	if len(uc.escalation.Offsets) == 0 {
+		return nil
+	}
+	users, err := uc.userRepo.GetUsersDueForEscalation(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to fetch users due for escalation: %w", err)
+	}
+
+	for i := range users {
+		user := &users[i]
+		next, err := domain.NextEscalationAfter(now, uc.escalation.Offsets)
+		if err != nil {
+			slog.WarnContext(ctx, "Cannot schedule escalation, parking it", "user_id", user.ID.Hex(), "error", err)
+			next = domain.ScheduleParked
+		}
+		claimed, err := uc.userRepo.ClaimEscalation(ctx, user.ID, user.NextEscalationAt, next)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to claim escalation", "user_id", user.ID.Hex(), "error", err)
+			continue
+		}
+		// A follow-up is only meaningful before its streak day has ended.
+		if !claimed || user.NextEscalationAt.IsZero() || next == domain.ScheduleParked ||
+			!domain.StreakDay(user.NextEscalationAt).Equal(domain.StreakDay(now)) {
+			continue
+		}
+
+		notification, err := uc.escalationNotification(ctx, user, user.NextEscalationAt)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping escalation", "user_id", user.ID.Hex(), "error", err)
+			continue
+		}
+		if notification == nil {
+			continue
+		}
+		err = uc.notificationUsecase.Enqueue(ctx, user, *notification)
+		if err != nil && err != domain.ErrNotificationDuplicate && err != domain.ErrNotificationSuppressed {
+			slog.ErrorContext(ctx, "Failed to queue escalation", "user_id", user.ID.Hex(), "error", err)
+		}
+	}
 	return nil
 }
 
+// escalationNotification builds the follow-up due at dueAt, or returns nil if
+// the user has already been consistent on the streak day dueAt falls in. Days
+// a platform could not be fetched never break a streak, so there is nothing
+// to escalate either.
+func (uc *consistencyUsecase) escalationNotification(ctx context.Context, user *domain.User, dueAt time.Time) (*notifications.Notification, error) {
+	day := domain.StreakDay(dueAt)
+	dailyConsistency, err := uc.consistencyRepo.GetDailyConsistency(ctx, user.ID, day)
+	if err != nil && err != domain.ErrConsistencyNotFound {
+		return nil, fmt.Errorf("error checking daily consistency for escalation: %w", err)
+	}
+	if dailyConsistency != nil && (dailyConsistency.OverallConsistent || dailyConsistency.Unknown()) {
+		return nil, nil
+	}
+
+	streak, err := uc.consistencyRepo.GetStreaks(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching streak for escalation: %w", err)
+	}
+
+	// The copy gives the end of the streak day on the user's own clock.
+	end := day.AddDate(0, 0, 1)
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	remaining := end.Sub(dueAt)
+
+	stage := uc.escalationStage(remaining)
+	title, body := escalationCopy(user.Locale, stage, len(uc.escalation.Offsets), user.Username, remaining, end.In(loc).Format("15:04"), streak.CurrentStreak)
+	notificationType := fmt.Sprintf("%s_%d", escalationNotificationType, stage+1)
+	return &notifications.Notification{
+		Type:  notificationType,
+		Kind:  domain.NotificationKindReminder,
+		Title: title,
+		Body:  body,
+		Data: map[string]string{
+			"type":          notificationType,
+			"userId":        user.ID.Hex(),
+			"currentStreak": strconv.Itoa(streak.CurrentStreak),
+		},
+	}, nil
+}
+
+// escalationStage maps the time left in the streak day to the index of the
+// closest configured offset; offsets are sorted largest first.
+func (uc *consistencyUsecase) escalationStage(remaining time.Duration) int {
+	stage := 0
+	for i, offset := range uc.escalation.Offsets {
+		if absDuration(offset-remaining) < absDuration(uc.escalation.Offsets[stage]-remaining) {
+			stage = i
+		}
+	}
+	return stage
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// escalationCopy gets more urgent as stage approaches the final follow-up.
+func escalationCopy(locale string, stage, stages int, username string, remaining time.Duration, endsAt string, streak int) (title, body string) {
+	tone := "middle"
+	switch {
+	case stage == stages-1:
+		tone = "last"
+	case stage == 0:
+		tone = "first"
+	}
+	bodyKey := "escalation." + tone + ".body"
+	if streak == 0 {
+		bodyKey += "_no_streak"
+	}
+	data := map[string]interface{}{
+		"Username": username,
+		"Left":     formatRemaining(locale, remaining),
+		"EndsAt":   endsAt,
+		"Streak":   streak,
+	}
+	return i18n.T(locale, "escalation."+tone+".title", nil), i18n.T(locale, bodyKey, data)
+}
+
+// formatRemaining renders a duration as e.g. "2 hours", "45 minutes" or "1 hour 30 minutes".
+func formatRemaining(locale string, d time.Duration) string {
+	d = d.Round(time.Minute)
+	data := map[string]int{
+		"Hours":   int(d / time.Hour),
+		"Minutes": int((d % time.Hour) / time.Minute),
+	}
+	switch {
+	case data["Hours"] > 0 && data["Minutes"] > 0:
+		return i18n.T(locale, "duration.hours_minutes", data)
+	case data["Hours"] > 0:
+		return i18n.T(locale, "duration.hours", data)
+	default:
+		return i18n.T(locale, "duration.minutes", data)
+	}
+}
 
-func (uc *consistencyUsecase) TriggerDailyConsistencyCheck(ctx context.Context) {
-	log.Println("Scheduler: Triggering daily consistency check for all users...") 
+// reminderNotification builds the reminder for a user who has not yet been
+// consistent on the given streak day, and returns nil if they already have.
+func (uc *consistencyUsecase) reminderNotification(ctx context.Context, user *domain.User, day time.Time) (*notifications.Notification, error) {
+	dailyConsistency, err := uc.consistencyRepo.GetDailyConsistency(ctx, user.ID, day)
+	if err != nil && err != domain.ErrConsistencyNotFound {
+		return nil, fmt.Errorf("error checking daily consistency for reminder: %w", err)
+	}
+	if dailyConsistency != nil && dailyConsistency.OverallConsistent {
+		return nil, nil
+	}
+
+	// Don't claim the user hasn't solved anything when we couldn't check.
+	key := "reminder"
+	if dailyConsistency != nil && dailyConsistency.Unknown() {
+		key = "reminder.unknown"
+	}
+	return &notifications.Notification{
+		Type:  reminderNotificationType,
+		Kind:  domain.NotificationKindReminder,
+		Title: i18n.T(user.Locale, key+".title", nil),
+		Body:  i18n.T(user.Locale, key+".body", map[string]string{"Username": user.Username}),
+		Data:  map[string]string{"type": reminderNotificationType, "userId": user.ID.Hex()},
+	}, nil
+}
+
+// unsyncedActivity records a platform whose fetch failed. An earlier
+// successful sync of the same day is kept rather than overwritten.
+func unsyncedActivity(existing *domain.DailyConsistency, platform, username string, date time.Time, err error) domain.PlatformActivity {
+	if existing != nil {
+		if previous := existing.GetPlatformActivity(platform); previous.Platform != "" && previous.Synced() {
+			return previous
+		}
+	}
+	status := domain.SyncStatusFailed
+	if errors.Is(err, domain.ErrPlatformUnavailable) {
+		status = domain.SyncStatusPending
+	}
+	return domain.PlatformActivity{
+		Platform:     platform,
+		Username:     username,
+		Date:         date,
+		IsConsistent: false,
+		SyncStatus:   status,
+		SyncError:    err.Error(),
+	}
+}
+
+// syncRetryBackoff spaces out re-checks of a day with unsynced platforms:
+// 5m, 10m, 20m, ... capped at 2h.
+func syncRetryBackoff(attempts int) time.Duration {
+	delay := syncRetryBaseBackoff
+	for i := 1; i < attempts && delay < syncRetryMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > syncRetryMaxBackoff {
+		delay = syncRetryMaxBackoff
+	}
+	return delay
+}
+
+// RetryUnsyncedDays re-checks today's records that still have a failed or
+// pending platform and are due for another attempt. Past days are closed:
+// whatever could not be fetched stays unknown, and stops holding the current
+// streak open after domain.UnknownDayGraceDays.
+func (uc *consistencyUsecase) RetryUnsyncedDays(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.RetryUnsyncedDays")
+	defer span.End()
+	days, err := uc.consistencyRepo.GetDaysDueForSync(ctx, now.UTC().Truncate(24*time.Hour), now)
+	if err != nil {
+		return fmt.Errorf("failed to fetch days due for sync: %w", err)
+	}
+	for _, day := range days {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := uc.checkUser(ctx, day.UserID.Hex()); err != nil {
+			slog.ErrorContext(ctx, "Error re-syncing consistency", "user_id", day.UserID.Hex(), "error", err)
+		}
+	}
+	return nil
+}
+
+// TriggerDailyConsistencyCheck checks every user through a pool of
+// checkPolicy.Concurrency workers. Cancelling ctx stops handing out users and
+// aborts in-flight checks; the summary then counts the rest as skipped.
+func (uc *consistencyUsecase) TriggerDailyConsistencyCheck(ctx context.Context) (*domain.ConsistencyRunSummary, error) {
+	ctx, span := tracing.Start(ctx, "ConsistencyUsecase.TriggerDailyConsistencyCheck")
+	defer span.End()
+	slog.InfoContext(ctx, "Triggering daily consistency check for all users")
+	summary := &domain.ConsistencyRunSummary{StartedAt: time.Now()}
 	users, err := uc.userRepo.GetAllUsers(ctx)
 	if err != nil {
-		log.Printf("Scheduler: Failed to get all users for daily check: %v", err) 
-		return
+		return nil, fmt.Errorf("failed to get all users for daily check: %w", err)
 	}
+	summary.Total = len(users)
 
+	workers := uc.checkPolicy.Concurrency
+	if workers > len(users) {
+		workers = len(users)
+	}
+	jobs := make(chan domain.User)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range jobs {
+				consistency, err := uc.checkUser(ctx, user.ID.Hex())
+				if err != nil {
+					slog.ErrorContext(ctx, "Error checking consistency", "user_id", user.ID.Hex(), "error", err)
+				}
+				mu.Lock()
+				summary.Checked++
+				if err != nil {
+					summary.Failed++
+				} else if consistency.OverallConsistent {
+					summary.Consistent++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
 	for _, user := range users {
-		_, err := uc.CheckDailyConsistency(ctx, user.ID.Hex())
-		if err != nil {
-			log.Printf("Scheduler: Error checking consistency for user %s (ID: %s): %v", user.Email, user.ID.Hex(), err) // Keep error log
+		select {
+		case jobs <- user:
+		case <-ctx.Done():
+			break feed
 		}
 	}
-	log.Println("Scheduler: Daily consistency check completed for all users.") // Keep this high-level log
-}
\ No newline at end of file
+	close(jobs)
+	wg.Wait()
+
+	summary.Skipped = summary.Total - summary.Checked
+	summary.Cancelled = ctx.Err() != nil
+	summary.Duration = time.Since(summary.StartedAt)
+	slog.InfoContext(ctx, "Daily consistency check finished",
+		"duration_ms", summary.Duration.Milliseconds(),
+		"total", summary.Total,
+		"checked", summary.Checked,
+		"consistent", summary.Consistent,
+		"failed", summary.Failed,
+		"skipped", summary.Skipped,
+		"cancelled", summary.Cancelled,
+	)
+	return summary, nil
+}
+
+// checkUser runs CheckDailyConsistency under the per-user timeout and records
+// its duration and outcome.
+func (uc *consistencyUsecase) checkUser(ctx context.Context, userID string) (*domain.DailyConsistency, error) {
+	if uc.checkPolicy.UserTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, uc.checkPolicy.UserTimeout)
+		defer cancel()
+	}
+	started := time.Now()
+	consistency, err := uc.CheckDailyConsistency(ctx, userID)
+
+	outcome := "error"
+	switch {
+	case err != nil:
+	case consistency.OverallConsistent:
+		outcome = "consistent"
+	case consistency.Unknown():
+		outcome = "unknown"
+	default:
+		outcome = "inconsistent"
+	}
+	metrics.ConsistencyChecks.WithLabelValues(outcome).Inc()
+	metrics.ConsistencyCheckDuration.WithLabelValues(outcome).Observe(metrics.Since(started))
+	return consistency, err
+}
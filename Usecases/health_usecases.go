@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+)
+
+// dependencyCheckTimeout bounds each readiness probe so one hung dependency
+// cannot stall the probe past the load balancer's own timeout.
+const dependencyCheckTimeout = 3 * time.Second
+
+// DependencyCheck probes one dependency for readiness. Check returns a short
+// human-readable detail, and an error if the dependency is not usable.
+type DependencyCheck struct {
+	Name  string
+	Check func(ctx context.Context) (string, error)
+}
+
+type HealthUsecase interface {
+	Ready(ctx context.Context) domain.Readiness
+	Status(ctx context.Context) (*domain.SystemStatus, error)
+}
+
+type healthUsecase struct {
+	checks           []DependencyCheck
+	platformUsecase  PlatformUsecase
+	jobRepo          repositories.JobRepository
+	notificationRepo repositories.NotificationRepository
+	leaseRepo        repositories.LeaseRepository
+}
+
+func NewHealthUsecase(
+	checks []DependencyCheck,
+	platformUsecase PlatformUsecase,
+	jobRepo repositories.JobRepository,
+	notificationRepo repositories.NotificationRepository,
+	leaseRepo repositories.LeaseRepository,
+) HealthUsecase {
+	return &healthUsecase{
+		checks:           checks,
+		platformUsecase:  platformUsecase,
+		jobRepo:          jobRepo,
+		notificationRepo: notificationRepo,
+		leaseRepo:        leaseRepo,
+	}
+}
+
+// Ready runs every dependency check concurrently.
+func (uc *healthUsecase) Ready(ctx context.Context) domain.Readiness {
+	readiness := domain.Readiness{
+		Ready:        true,
+		Dependencies: make([]domain.DependencyStatus, len(uc.checks)),
+		CheckedAt:    time.Now(),
+	}
+	var wg sync.WaitGroup
+	for i, check := range uc.checks {
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+			readiness.Dependencies[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+	for _, dependency := range readiness.Dependencies {
+		if dependency.Status != domain.DependencyUp {
+			readiness.Ready = false
+		}
+	}
+	return readiness
+}
+
+func runCheck(ctx context.Context, check DependencyCheck) domain.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, dependencyCheckTimeout)
+	defer cancel()
+	started := time.Now()
+	detail, err := check.Check(ctx)
+	status := domain.DependencyStatus{
+		Name:    check.Name,
+		Status:  domain.DependencyUp,
+		Detail:  detail,
+		Latency: time.Since(started),
+	}
+	if err != nil {
+		status.Status = domain.DependencyDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (uc *healthUsecase) Status(ctx context.Context) (*domain.SystemStatus, error) {
+	ctx, span := tracing.Start(ctx, "HealthUsecase.Status")
+	defer span.End()
+	status := &domain.SystemStatus{
+		Readiness: uc.Ready(ctx),
+		Platforms: uc.platformUsecase.GetPlatformStatuses(),
+	}
+
+	runs, err := uc.jobRepo.ListRuns(ctx, domain.JobFilter{Type: domain.JobTypeDailyConsistencyCheck, Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load last nightly run: %w", err)
+	}
+	if len(runs) > 0 {
+		status.LastNightlyRun = &runs[0]
+	}
+
+	if status.SchedulerLease, err = uc.leaseRepo.Get(ctx, domain.SchedulerLeaseName); err != nil {
+		return nil, fmt.Errorf("failed to load scheduler lease: %w", err)
+	}
+	if status.Queue.Jobs, err = uc.jobRepo.CountByStatus(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	if status.Queue.PendingNotifications, err = uc.notificationRepo.CountPending(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count pending notifications: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,353 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/logging"
+	"consistent_1/Infrastructure/metrics"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	jobLease           = 2 * time.Minute // renewed every jobLease/3 while the job runs
+	jobMaxAttempts     = 5
+	jobBaseBackoff     = time.Minute
+	jobMaxBackoff      = time.Hour
+	defaultJobPageSize = 50
+)
+
+// JobHandler runs one job. Its result, if any, is stored on the job run.
+type JobHandler func(ctx context.Context, job *domain.Job) (interface{}, error)
+
+type JobUsecase interface {
+	// Enqueue queues a job of jobType to run at runAt (now if zero). A non-empty
+	// dedupKey makes the call a no-op returning domain.ErrJobDuplicate when a
+	// job with that key already exists.
+	Enqueue(ctx context.Context, jobType string, payload map[string]string, dedupKey string, runAt time.Time) (*domain.Job, error)
+	// RunNextJob claims and runs the oldest due job, reporting false if no job
+	// was due.
+	RunNextJob(ctx context.Context) (bool, error)
+	ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error)
+	GetJob(ctx context.Context, id string) (*domain.JobDetail, error)
+	ListRuns(ctx context.Context, filter domain.JobFilter) ([]domain.JobRun, error)
+	Requeue(ctx context.Context, id string) (*domain.Job, error)
+}
+
+type jobUsecase struct {
+	jobRepo  repositories.JobRepository
+	handlers map[string]JobHandler
+	worker   string
+}
+
+func NewJobUsecase(jobRepo repositories.JobRepository, handlers map[string]JobHandler) JobUsecase {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &jobUsecase{
+		jobRepo:  jobRepo,
+		handlers: handlers,
+		worker:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// NewConsistencyJobHandlers maps the consistency job types onto consistencyUsecase.
+func NewConsistencyJobHandlers(consistencyUsecase ConsistencyUsecase) map[string]JobHandler {
+	return map[string]JobHandler{
+		domain.JobTypeDailyConsistencyCheck: func(ctx context.Context, job *domain.Job) (interface{}, error) {
+			summary, err := consistencyUsecase.TriggerDailyConsistencyCheck(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if summary.Cancelled {
+				return summary, ctx.Err()
+			}
+			return summary, nil
+		},
+		domain.JobTypeDispatchReminders: func(ctx context.Context, job *domain.Job) (interface{}, error) {
+			return nil, consistencyUsecase.DispatchDueReminders(ctx, time.Now().UTC())
+		},
+		domain.JobTypeDispatchEscalations: func(ctx context.Context, job *domain.Job) (interface{}, error) {
+			return nil, consistencyUsecase.DispatchStreakEscalations(ctx, time.Now().UTC())
+		},
+	}
+}
+
+func (uc *jobUsecase) Enqueue(ctx context.Context, jobType string, payload map[string]string, dedupKey string, runAt time.Time) (*domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobUsecase.Enqueue")
+	defer span.End()
+	if _, ok := uc.handlers[jobType]; !ok {
+		return nil, domain.ErrUnsupportedJobType
+	}
+	job := &domain.Job{
+		Type:        jobType,
+		Payload:     payload,
+		DedupKey:    dedupKey,
+		MaxAttempts: jobMaxAttempts,
+		RunAt:       runAt,
+	}
+	if err := uc.jobRepo.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (uc *jobUsecase) RunNextJob(ctx context.Context) (bool, error) {
+	job, err := uc.jobRepo.ClaimNext(ctx, time.Now(), jobLease)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim next job: %w", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+	uc.run(ctx, job)
+	return true, nil
+}
+
+// run executes a claimed job and records the attempt. Failures are retried
+// with exponential backoff until the job's MaxAttempts, after which it is
+// dead-lettered. A run cut short by ctx is put back on the queue without
+// using up an attempt.
+func (uc *jobUsecase) run(ctx context.Context, job *domain.Job) {
+	// Each run is a trace of its own, whichever worker tick picked it up.
+	ctx, span := tracing.StartRoot(ctx, "job "+job.Type,
+		attribute.String("job.id", job.ID.Hex()),
+		attribute.Int("job.attempt", job.Attempts))
+	defer span.End()
+	ctx = logging.With(ctx, slog.String("job_id", job.ID.Hex()), slog.String("job_type", job.Type))
+	if err := uc.jobRepo.AbandonRuns(ctx, job.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to close abandoned job runs", "error", err)
+	}
+
+	// A job whose worker died on its last attempt comes back with Attempts
+	// past the limit.
+	if job.Attempts > job.MaxAttempts {
+		job.Status = domain.JobStatusDead
+		job.LastError = "worker stopped before the last attempt finished"
+		uc.finish(ctx, job)
+		return
+	}
+
+	run := &domain.JobRun{
+		JobID:     job.ID,
+		Type:      job.Type,
+		Attempt:   job.Attempts,
+		Worker:    uc.worker,
+		StartedAt: time.Now(),
+	}
+	if err := uc.jobRepo.StartRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "Failed to record job run", "error", err)
+	} else {
+		ctx = logging.With(ctx, slog.String("job_run_id", run.ID.Hex()))
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	heartbeatDone := make(chan struct{})
+	leaseLost := false
+	go func() {
+		defer close(heartbeatDone)
+		leaseLost = !uc.heartbeat(runCtx, job)
+		cancel()
+	}()
+	result, err := uc.execute(runCtx, job)
+	cancel()
+	<-heartbeatDone
+
+	run.Result = resultDocument(result)
+	now := time.Now()
+	defer func() {
+		metrics.JobRuns.WithLabelValues(job.Type, run.Status).Inc()
+		metrics.JobRunDuration.WithLabelValues(job.Type).Observe(now.Sub(run.StartedAt).Seconds())
+		span.SetAttributes(attribute.String("job.run_status", run.Status))
+		if err != nil {
+			tracing.Fail(span, err)
+		}
+	}()
+	switch {
+	case leaseLost:
+		// The job belongs to another worker now; only close our run.
+		run.Status = domain.JobRunAbandoned
+		run.Error = "lease lost to another worker"
+	case err == nil:
+		job.Status = domain.JobStatusSucceeded
+		job.LastError = ""
+		run.Status = domain.JobRunSucceeded
+	case ctx.Err() != nil:
+		job.Status = domain.JobStatusQueued
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = err.Error()
+		run.Status = domain.JobRunAbandoned
+		run.Error = err.Error()
+	case err == domain.ErrUnsupportedJobType || job.Attempts >= job.MaxAttempts:
+		job.Status = domain.JobStatusDead
+		job.LastError = err.Error()
+		run.Status = domain.JobRunFailed
+		run.Error = err.Error()
+		slog.ErrorContext(ctx, "Job dead-lettered", "attempts", job.Attempts, "error", err)
+	default:
+		job.Status = domain.JobStatusFailed
+		job.RunAt = now.Add(jobBackoff(job.Attempts))
+		job.LastError = err.Error()
+		run.Status = domain.JobRunFailed
+		run.Error = err.Error()
+		slog.WarnContext(ctx, "Job attempt failed, will retry", "attempt", job.Attempts, "retry_at", job.RunAt.Format(time.RFC3339), "error", err)
+	}
+
+	// Record the outcome even if ctx has just been cancelled.
+	saveCtx, saveCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer saveCancel()
+	if !run.ID.IsZero() {
+		if err := uc.jobRepo.FinishRun(saveCtx, run); err != nil {
+			slog.ErrorContext(ctx, "Failed to record job run outcome", "error", err)
+		}
+	}
+	if !leaseLost {
+		uc.finish(saveCtx, job)
+	}
+}
+
+// resultDocument stores a handler's result in the shape it has in API
+// responses, so run history reads back the same as it was written.
+func resultDocument(result interface{}) map[string]interface{} {
+	if result == nil {
+		return nil
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return map[string]interface{}{"value": string(raw)}
+	}
+	return document
+}
+
+func (uc *jobUsecase) finish(ctx context.Context, job *domain.Job) {
+	if err := uc.jobRepo.Finish(ctx, job); err != nil {
+		slog.ErrorContext(ctx, "Failed to save job outcome", "error", err)
+	}
+}
+
+// execute runs the job's handler, turning a panic into an error so one bad
+// job cannot take the worker down.
+func (uc *jobUsecase) execute(ctx context.Context, job *domain.Job) (result interface{}, err error) {
+	handler, ok := uc.handlers[job.Type]
+	if !ok {
+		return nil, domain.ErrUnsupportedJobType
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// heartbeat extends the job's lease until ctx ends. It returns false as soon
+// as another worker has taken the job over.
+func (uc *jobUsecase) heartbeat(ctx context.Context, job *domain.Job) bool {
+	ticker := time.NewTicker(jobLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+			err := uc.jobRepo.ExtendLease(ctx, job, time.Now().Add(jobLease))
+			if err == domain.ErrLeaseHeld {
+				slog.WarnContext(ctx, "Job lease lost to another worker, stopping run")
+				return false
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to extend job lease", "error", err)
+			}
+		}
+	}
+}
+
+// jobBackoff doubles the wait after each failed attempt, capped at jobMaxBackoff.
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobBaseBackoff
+	for i := 1; i < attempts && backoff < jobMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > jobMaxBackoff {
+		backoff = jobMaxBackoff
+	}
+	return backoff
+}
+
+func (uc *jobUsecase) ListJobs(ctx context.Context, filter domain.JobFilter) ([]domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobUsecase.ListJobs")
+	defer span.End()
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobPageSize
+	}
+	jobs, err := uc.jobRepo.ListJobs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if jobs == nil {
+		jobs = []domain.Job{}
+	}
+	return jobs, nil
+}
+
+func (uc *jobUsecase) GetJob(ctx context.Context, id string) (*domain.JobDetail, error) {
+	ctx, span := tracing.Start(ctx, "JobUsecase.GetJob")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrJobNotFound
+	}
+	job, err := uc.jobRepo.GetJob(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	runs, err := uc.jobRepo.ListRuns(ctx, domain.JobFilter{JobID: objID})
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []domain.JobRun{}
+	}
+	return &domain.JobDetail{Job: *job, Runs: runs}, nil
+}
+
+func (uc *jobUsecase) ListRuns(ctx context.Context, filter domain.JobFilter) ([]domain.JobRun, error) {
+	ctx, span := tracing.Start(ctx, "JobUsecase.ListRuns")
+	defer span.End()
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobPageSize
+	}
+	runs, err := uc.jobRepo.ListRuns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []domain.JobRun{}
+	}
+	return runs, nil
+}
+
+func (uc *jobUsecase) Requeue(ctx context.Context, id string) (*domain.Job, error) {
+	ctx, span := tracing.Start(ctx, "JobUsecase.Requeue")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrJobNotFound
+	}
+	return uc.jobRepo.Requeue(ctx, objID)
+}
@@ -0,0 +1,379 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/metrics"
+	"consistent_1/Infrastructure/notifications"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	outboxBatchSize   = notifications.MaxBatchSize
+	outboxLease       = 5 * time.Minute
+	outboxMaxAttempts = 5
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 30 * time.Minute
+)
+
+type NotificationUsecase interface {
+	Enqueue(ctx context.Context, user *domain.User, notification notifications.Notification) error
+	ProcessOutbox(ctx context.Context) error
+	CancelPending(ctx context.Context, userID primitive.ObjectID, typePrefixes ...string) error
+	GetUserNotifications(ctx context.Context, userID string, limit, offset int64) ([]domain.NotificationRecord, error)
+	GetInbox(ctx context.Context, userID string, limit, offset int64) (*domain.InboxPage, error)
+	MarkInboxItemRead(ctx context.Context, userID, itemID string) error
+	MarkAllInboxItemsRead(ctx context.Context, userID string) (int64, error)
+}
+
+type notificationUsecase struct {
+	userRepo         repositories.UserRepository
+	notificationRepo repositories.NotificationRepository
+	inboxRepo        repositories.InboxRepository
+	notifiers        map[string]notifications.Notifier
+}
+
+func NewNotificationUsecase(
+	userRepo repositories.UserRepository,
+	notificationRepo repositories.NotificationRepository,
+	inboxRepo repositories.InboxRepository,
+	notifiers []notifications.Notifier,
+) NotificationUsecase {
+	byChannel := make(map[string]notifications.Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byChannel[n.Channel()] = n
+	}
+	return &notificationUsecase{
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		inboxRepo:        inboxRepo,
+		notifiers:        byChannel,
+	}
+}
+
+// Enqueue copies the notification into the user's inbox and records it in the
+// outbox with one pending delivery per channel the user chose for its kind. It
+// returns domain.ErrNotificationSuppressed if the user turned the kind off, and
+// domain.ErrNotificationDuplicate if the user was already sent a notification
+// of this type on their current local day. Notifications raised during quiet
+// hours are held until the window ends; one that would expire before then is
+// not delivered, but still lands in the inbox.
+func (uc *notificationUsecase) Enqueue(ctx context.Context, user *domain.User, notification notifications.Notification) error {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.Enqueue")
+	defer span.End()
+	if notification.Kind != "" && !user.KindEnabled(notification.Kind) {
+		return domain.ErrNotificationSuppressed
+	}
+
+	now := time.Now()
+	localDate := user.LocalDate(now)
+	record := &domain.NotificationRecord{
+		UserID:        user.ID,
+		Type:          notification.Type,
+		Kind:          notification.Kind,
+		Title:         notification.Title,
+		Body:          notification.Body,
+		HTMLBody:      notification.HTMLBody,
+		Data:          notification.Data,
+		LocalDate:     localDate,
+		DedupKey:      fmt.Sprintf("%s/%s/%s", user.ID.Hex(), notification.Type, localDate),
+		NextAttemptAt: now,
+	}
+	deliver := true
+	if quietUntil, quiet := user.QuietUntil(now); quiet {
+		if expiresAtLocalMidnight(record.Kind) && user.LocalDate(quietUntil) != localDate {
+			deliver = false
+		}
+		record.NextAttemptAt = quietUntil
+	}
+
+	var err error
+	if deliver {
+		for _, channel := range user.ChannelsFor(notification.Kind) {
+			status := domain.NotificationStatusPending
+			lastError := ""
+			if _, ok := uc.notifiers[channel]; !ok {
+				status = domain.NotificationStatusSkipped
+				lastError = "channel not configured on this server"
+			}
+			record.Channels = append(record.Channels, domain.ChannelDelivery{Channel: channel, Status: status, LastError: lastError})
+		}
+		err = uc.notificationRepo.Enqueue(ctx, record)
+		if err == domain.ErrNotificationDuplicate {
+			record.ID = primitive.NilObjectID
+		} else if err != nil {
+			return err
+		}
+	} else {
+		record.CreatedAt = now
+	}
+
+	inboxErr := uc.inboxRepo.Add(ctx, &domain.InboxItem{
+		UserID:         user.ID,
+		NotificationID: record.ID,
+		Type:           record.Type,
+		Kind:           record.Kind,
+		Title:          record.Title,
+		Body:           record.Body,
+		Data:           record.Data,
+		DedupKey:       record.DedupKey,
+		CreatedAt:      record.CreatedAt,
+	})
+	if inboxErr != nil && inboxErr != domain.ErrInboxItemDuplicate {
+		slog.WarnContext(ctx, "Failed to add notification to inbox", "notification_id", record.ID.Hex(), "user_id", user.ID.Hex(), "error", inboxErr)
+	}
+	if !deliver {
+		return domain.ErrNotificationSuppressed
+	}
+	return err
+}
+
+// expiresAtLocalMidnight reports whether notifications of this kind are only
+// meaningful on the local day they were raised, such as "solve today's problem".
+func expiresAtLocalMidnight(kind string) bool {
+	return kind == domain.NotificationKindReminder
+}
+
+// ProcessOutbox claims due outbox records, delivers their pending channels in
+// per-channel batches and records the outcome, scheduling retries with
+// exponential backoff until outboxMaxAttempts is reached.
+func (uc *notificationUsecase) ProcessOutbox(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.ProcessOutbox")
+	defer span.End()
+	for {
+		records, err := uc.notificationRepo.ClaimDue(ctx, time.Now(), outboxLease, outboxBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim due notifications: %w", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		uc.deliver(ctx, records)
+		if len(records) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+type pendingDelivery struct {
+	record  *domain.NotificationRecord
+	channel int
+}
+
+func (uc *notificationUsecase) deliver(ctx context.Context, records []domain.NotificationRecord) {
+	users := make(map[primitive.ObjectID]*domain.User)
+	batches := make(map[string][]notifications.Delivery)
+	targets := make(map[string][]pendingDelivery)
+
+	for i := range records {
+		record := &records[i]
+		user, ok := users[record.UserID]
+		if !ok {
+			var err error
+			user, err = uc.userRepo.GetUserByID(ctx, record.UserID.Hex())
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to load notification recipient", "notification_id", record.ID.Hex(), "user_id", record.UserID.Hex(), "error", err)
+				user = nil
+			}
+			users[record.UserID] = user
+		}
+
+		if user != nil && uc.holdForQuietHours(record, user) {
+			continue
+		}
+
+		for j := range record.Channels {
+			ch := &record.Channels[j]
+			if ch.Status != domain.NotificationStatusPending {
+				continue
+			}
+			if _, ok := uc.notifiers[ch.Channel]; !ok {
+				// The channel was configured when this was queued but has no notifier here.
+				ch.Attempts++
+				ch.Status = domain.NotificationStatusSkipped
+				ch.LastError = domain.ErrChannelNotConfigured.Error()
+				metrics.Notifications.WithLabelValues(ch.Channel, domain.NotificationStatusSkipped).Inc()
+				continue
+			}
+			if user == nil {
+				ch.Attempts++
+				ch.LastError = "user could not be loaded"
+				continue
+			}
+			batches[ch.Channel] = append(batches[ch.Channel], notifications.Delivery{
+				User: user,
+				Notification: notifications.Notification{
+					Type:     record.Type,
+					Title:    record.Title,
+					Body:     record.Body,
+					HTMLBody: record.HTMLBody,
+					Data:     record.Data,
+				},
+			})
+			targets[ch.Channel] = append(targets[ch.Channel], pendingDelivery{record: record, channel: j})
+		}
+	}
+
+	now := time.Now()
+	for channel, batch := range batches {
+		errs := uc.notifiers[channel].Notify(ctx, batch)
+		for i, target := range targets[channel] {
+			ch := &target.record.Channels[target.channel]
+			ch.Attempts++
+			switch err := errs[i]; {
+			case err == nil:
+				ch.Status = domain.NotificationStatusSent
+				ch.LastError = ""
+				ch.SentAt = &now
+			case errors.Is(err, domain.ErrRecipientNotConfigured), errors.Is(err, domain.ErrChannelNotConfigured):
+				ch.Status = domain.NotificationStatusSkipped
+				ch.LastError = err.Error()
+			default:
+				ch.LastError = err.Error()
+			}
+			outcome := ch.Status
+			if outcome == domain.NotificationStatusPending {
+				outcome = "error"
+			}
+			metrics.Notifications.WithLabelValues(channel, outcome).Inc()
+		}
+	}
+
+	for i := range records {
+		record := &records[i]
+		if record.Status != domain.NotificationStatusPending || record.NextAttemptAt.After(now) {
+			// Held or cancelled by holdForQuietHours; nothing was attempted.
+			if err := uc.notificationRepo.SaveDeliveryState(ctx, record); err != nil {
+				slog.ErrorContext(ctx, "Failed to save notification delivery state", "notification_id", record.ID.Hex(), "error", err)
+			}
+			continue
+		}
+		record.Attempts++
+		if record.Attempts >= outboxMaxAttempts {
+			for j := range record.Channels {
+				if record.Channels[j].Status == domain.NotificationStatusPending {
+					record.Channels[j].Status = domain.NotificationStatusFailed
+					metrics.Notifications.WithLabelValues(record.Channels[j].Channel, domain.NotificationStatusFailed).Inc()
+				}
+			}
+		}
+		record.Status = record.SettleStatus()
+		if record.Status == domain.NotificationStatusPending {
+			record.NextAttemptAt = now.Add(outboxBackoff(record.Attempts))
+		}
+		if err := uc.notificationRepo.SaveDeliveryState(ctx, record); err != nil {
+			slog.ErrorContext(ctx, "Failed to save notification delivery state", "notification_id", record.ID.Hex(), "error", err)
+		}
+	}
+}
+
+// holdForQuietHours postpones a record that came due inside the user's quiet
+// hours (for example on a retry), or cancels it if it would only arrive after
+// it stopped being relevant. It reports whether the record was held back.
+func (uc *notificationUsecase) holdForQuietHours(record *domain.NotificationRecord, user *domain.User) bool {
+	quietUntil, quiet := user.QuietUntil(time.Now())
+	if !quiet {
+		return false
+	}
+	if expiresAtLocalMidnight(record.Kind) && user.LocalDate(quietUntil) != record.LocalDate {
+		record.Status = domain.NotificationStatusCancelled
+		return true
+	}
+	record.NextAttemptAt = quietUntil
+	return true
+}
+
+// outboxBackoff doubles the wait after each failed attempt, capped at outboxMaxBackoff.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+// CancelPending withdraws queued notifications that have not been delivered
+// yet, and removes the unread inbox copies raised since the start of the
+// current streak day.
+func (uc *notificationUsecase) CancelPending(ctx context.Context, userID primitive.ObjectID, typePrefixes ...string) error {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.CancelPending")
+	defer span.End()
+	cancelled, err := uc.notificationRepo.CancelPending(ctx, userID, typePrefixes)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending notifications: %w", err)
+	}
+	if cancelled > 0 {
+		slog.InfoContext(ctx, "Cancelled pending notifications", "count", cancelled, "user_id", userID.Hex())
+	}
+	removed, err := uc.inboxRepo.RemoveUnread(ctx, userID, typePrefixes, domain.StreakDay(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to remove cancelled notifications from inbox: %w", err)
+	}
+	if removed > 0 {
+		slog.InfoContext(ctx, "Removed cancelled notifications from inbox", "count", removed, "user_id", userID.Hex())
+	}
+	return nil
+}
+
+func (uc *notificationUsecase) GetUserNotifications(ctx context.Context, userID string, limit, offset int64) ([]domain.NotificationRecord, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.GetUserNotifications")
+	defer span.End()
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return uc.notificationRepo.GetUserNotifications(ctx, objUserID, limit, offset)
+}
+
+func (uc *notificationUsecase) GetInbox(ctx context.Context, userID string, limit, offset int64) (*domain.InboxPage, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.GetInbox")
+	defer span.End()
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+	items, err := uc.inboxRepo.GetUserInbox(ctx, objUserID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := uc.inboxRepo.CountUnread(ctx, objUserID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.InboxPage{Items: items, UnreadCount: unread}, nil
+}
+
+func (uc *notificationUsecase) MarkInboxItemRead(ctx context.Context, userID, itemID string) error {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.MarkInboxItemRead")
+	defer span.End()
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	objItemID, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return domain.ErrInboxItemNotFound
+	}
+	return uc.inboxRepo.MarkRead(ctx, objUserID, objItemID)
+}
+
+func (uc *notificationUsecase) MarkAllInboxItemsRead(ctx context.Context, userID string) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUsecase.MarkAllInboxItemsRead")
+	defer span.End()
+	objUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, domain.ErrUserNotFound
+	}
+	return uc.inboxRepo.MarkAllRead(ctx, objUserID)
+}
@@ -5,11 +5,13 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"consistent_1/Domain"
 	"consistent_1/Infrastructure/platform_api" 
+	"consistent_1/Infrastructure/tracing"
 	"consistent_1/Repositories"
 
 )
@@ -22,6 +24,9 @@ type PlatformUsecase interface {
 	
 	FetchLeetCodeActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error)
 	FetchCodeforcesActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error)
+
+	ValidatePlatformUsername(ctx context.Context, platform, username string) error
+	GetPlatformStatuses() []domain.PlatformStatus
 }
 
 type platformUsecase struct {
@@ -48,6 +53,8 @@ func NewPlatformUsecase(
 
 
 func (uc *platformUsecase) FetchUserDailyActivity(ctx context.Context, userID string, date time.Time) ([]domain.PlatformActivity, error) {
+	ctx, span := tracing.Start(ctx, "PlatformUsecase.FetchUserDailyActivity")
+	defer span.End()
 	user, err := uc.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -62,20 +69,24 @@ func (uc *platformUsecase) FetchUserDailyActivity(ctx context.Context, userID st
 	if lcUsername, ok := user.PlatformUsernames["leetcode"]; ok && lcUsername != "" {
 		
 		activity, err := uc.FetchLeetCodeActivity(ctx, lcUsername, queryDate)
-		if err != nil {
+		if err != nil && !errors.Is(err, domain.ErrPlatformDisabled) {
 			return nil, fmt.Errorf("failed to fetch LeetCode activity: %w", err)
 		}
-		allActivities = append(allActivities, activity)
+		if err == nil {
+			allActivities = append(allActivities, activity)
+		}
 	}
 
 	
 	if cfUsername, ok := user.PlatformUsernames["codeforces"]; ok && cfUsername != "" {
 		
 		activity, err := uc.FetchCodeforcesActivity(ctx, cfUsername, queryDate)
-		if err != nil {
+		if err != nil && !errors.Is(err, domain.ErrPlatformDisabled) {
 			return nil, fmt.Errorf("failed to fetch Codeforces activity: %w", err)
 		}
-		allActivities = append(allActivities, activity)
+		if err == nil {
+			allActivities = append(allActivities, activity)
+		}
 	}
 
 
@@ -85,12 +96,42 @@ func (uc *platformUsecase) FetchUserDailyActivity(ctx context.Context, userID st
 
 
 func (uc *platformUsecase) FetchLeetCodeActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error) {
+	ctx, span := tracing.Start(ctx, "PlatformUsecase.FetchLeetCodeActivity")
+	defer span.End()
 	
 	return uc.leetcodeAPI.FetchUserDailyActivity(ctx, username, date)
 }
 
 
 func (uc *platformUsecase) FetchCodeforcesActivity(ctx context.Context, username string, date time.Time) (domain.PlatformActivity, error) {
+	ctx, span := tracing.Start(ctx, "PlatformUsecase.FetchCodeforcesActivity")
+	defer span.End()
 	return uc.codeforcesAPI.FetchUserDailyActivity(ctx, username, date)
 }
 
+
+
+// ValidatePlatformUsername confirms that username exists on platform. Failures
+// are returned as *domain.PlatformHandleError so callers can name the bad handle.
+func (uc *platformUsecase) ValidatePlatformUsername(ctx context.Context, platform, username string) error {
+	ctx, span := tracing.Start(ctx, "PlatformUsecase.ValidatePlatformUsername")
+	defer span.End()
+	var err error
+	switch platform {
+	case "leetcode":
+		err = uc.leetcodeAPI.ValidateUsername(ctx, username)
+	case "codeforces":
+		err = uc.codeforcesAPI.ValidateUsername(ctx, username)
+	default:
+		err = domain.ErrUnsupportedPlatform
+	}
+	if err != nil {
+		return &domain.PlatformHandleError{Platform: platform, Username: username, Err: err}
+	}
+	return nil
+}
+
+// GetPlatformStatuses reports the rate limiter and circuit breaker of each platform client.
+func (uc *platformUsecase) GetPlatformStatuses() []domain.PlatformStatus {
+	return []domain.PlatformStatus{uc.leetcodeAPI.Status(), uc.codeforcesAPI.Status()}
+}
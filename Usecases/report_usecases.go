@@ -0,0 +1,357 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"consistent_1/Domain"
+	"consistent_1/Infrastructure/i18n"
+	"consistent_1/Infrastructure/notifications"
+	"consistent_1/Infrastructure/tracing"
+	"consistent_1/Repositories"
+)
+
+type ReportUsecase interface {
+	// GetReport builds the report for the period named by label ("2026-W42" for
+	// weekly, "2026-10" for monthly); an empty label means the user's current period.
+	GetReport(ctx context.Context, userID, period, label string) (*domain.ProgressReport, error)
+	DispatchDigests(ctx context.Context, now time.Time) error
+}
+
+type reportUsecase struct {
+	userRepo            repositories.UserRepository
+	consistencyUsecase  ConsistencyUsecase
+	notificationUsecase NotificationUsecase
+	digestTime          string
+}
+
+// NewReportUsecase creates a ReportUsecase that sends digests at digestTime
+// (HH:MM) in each user's timezone.
+func NewReportUsecase(
+	userRepo repositories.UserRepository,
+	consistencyUsecase ConsistencyUsecase,
+	notificationUsecase NotificationUsecase,
+	digestTime string,
+) ReportUsecase {
+	return &reportUsecase{
+		userRepo:            userRepo,
+		consistencyUsecase:  consistencyUsecase,
+		notificationUsecase: notificationUsecase,
+		digestTime:          digestTime,
+	}
+}
+
+func (uc *reportUsecase) GetReport(ctx context.Context, userID, period, label string) (*domain.ProgressReport, error) {
+	ctx, span := tracing.Start(ctx, "ReportUsecase.GetReport")
+	defer span.End()
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if label == "" {
+		label = periodLabel(period, localDay(user, time.Now()))
+	}
+	return uc.buildReport(ctx, user, period, label)
+}
+
+// DispatchDigests queues the weekly and monthly digests that fell due at or
+// before now, claiming each user's slot first as DispatchDueReminders does.
+func (uc *reportUsecase) DispatchDigests(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "ReportUsecase.DispatchDigests")
+	defer span.End()
+	for _, period := range []string{domain.ReportPeriodWeekly, domain.ReportPeriodMonthly} {
+		users, err := uc.userRepo.GetUsersDueForDigest(ctx, period, now)
+		if err != nil {
+			return fmt.Errorf("failed to fetch users due for %s digest: %w", period, err)
+		}
+		for i := range users {
+			uc.dispatchDigest(ctx, &users[i], period, now)
+		}
+	}
+	return nil
+}
+
+func (uc *reportUsecase) dispatchDigest(ctx context.Context, user *domain.User, period string, now time.Time) {
+	dueAt := user.NextWeeklyDigestAt
+	if period == domain.ReportPeriodMonthly {
+		dueAt = user.NextMonthlyDigestAt
+	}
+
+	next, err := user.NextDigestAfter(now, period, uc.digestTime)
+	if err != nil {
+		slog.WarnContext(ctx, "Cannot schedule digest, parking it", "period", period, "user_id", user.ID.Hex(), "error", err)
+		next = domain.ScheduleParked
+	}
+	claimed, err := uc.userRepo.ClaimDigest(ctx, user.ID, period, dueAt, next)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to claim digest", "period", period, "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+	if !claimed || dueAt.IsZero() || next == domain.ScheduleParked || isStaleSchedule(*user, dueAt, now) {
+		return
+	}
+
+	report, err := uc.buildReport(ctx, user, period, periodLabel(period, localDay(user, dueAt)))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to build digest", "period", period, "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+	notification, err := renderDigest(user, report)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render digest", "period", period, "user_id", user.ID.Hex(), "error", err)
+		return
+	}
+	err = uc.notificationUsecase.Enqueue(ctx, user, *notification)
+	if err != nil && err != domain.ErrNotificationDuplicate && err != domain.ErrNotificationSuppressed {
+		slog.ErrorContext(ctx, "Failed to queue digest", "period", period, "user_id", user.ID.Hex(), "error", err)
+	}
+}
+
+func (uc *reportUsecase) buildReport(ctx context.Context, user *domain.User, period, label string) (*domain.ProgressReport, error) {
+	start, end, err := periodBounds(period, label)
+	if err != nil {
+		return nil, err
+	}
+	prevStart, prevEnd := previousPeriod(period, start)
+
+	userID := user.ID.Hex()
+	history, err := uc.consistencyUsecase.GetConsistencyHistory(ctx, userID, nil, &end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load consistency history: %w", err)
+	}
+	streaks, err := uc.consistencyUsecase.GetStreaks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load streaks: %w", err)
+	}
+
+	report := &domain.ProgressReport{
+		Period:             period,
+		Label:              label,
+		StartDate:          start,
+		EndDate:            end,
+		TotalDays:          int(end.Sub(start).Hours()/24) + 1,
+		ProblemsByPlatform: make(map[string]int),
+		StreakAtStart:      domain.StreakAtEndOf(history, start.AddDate(0, 0, -1)),
+		LongestStreak:      streaks.LongestStreak,
+	}
+
+	todayUTC := time.Now().UTC().Truncate(24 * time.Hour)
+	if end.Before(todayUTC) {
+		report.StreakAtEnd = domain.StreakAtEndOf(history, end)
+	} else {
+		report.StreakAtEnd = streaks.CurrentStreak
+	}
+	report.StreakChange = report.StreakAtEnd - report.StreakAtStart
+
+	prevConsistent, prevProblems := 0, 0
+	for _, dc := range history {
+		date := dc.Date.UTC()
+		solved := 0
+		for _, activity := range dc.PlatformActivities {
+			solved += activity.ProblemsSolved
+		}
+
+		switch {
+		case !date.Before(start) && !date.After(end):
+			if dc.OverallConsistent {
+				report.DaysConsistent++
+			}
+			for _, activity := range dc.PlatformActivities {
+				report.ProblemsByPlatform[activity.Platform] += activity.ProblemsSolved
+			}
+			report.TotalProblems += solved
+			if solved > 0 && (report.BestDay == nil || solved > report.BestDay.ProblemsSolved) {
+				report.BestDay = &domain.DaySummary{Date: date, ProblemsSolved: solved}
+			}
+		case !date.Before(prevStart) && !date.After(prevEnd):
+			if dc.OverallConsistent {
+				prevConsistent++
+			}
+			prevProblems += solved
+		}
+	}
+
+	report.Previous = &domain.PeriodTotals{
+		Label:               periodLabel(period, prevStart),
+		DaysConsistent:      prevConsistent,
+		TotalProblems:       prevProblems,
+		DaysConsistentDelta: report.DaysConsistent - prevConsistent,
+		TotalProblemsDelta:  report.TotalProblems - prevProblems,
+	}
+	return report, nil
+}
+
+// localDay returns the user's local calendar date at t as a UTC-midnight date,
+// the same form consistency records are keyed by.
+func localDay(user *domain.User, t time.Time) time.Time {
+	date, _ := time.Parse("2006-01-02", user.LocalDate(t))
+	return date
+}
+
+func periodLabel(period string, day time.Time) string {
+	if period == domain.ReportPeriodMonthly {
+		return day.Format("2006-01")
+	}
+	year, week := day.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// periodBounds returns the first and last day (inclusive, UTC midnight) of the
+// ISO week or calendar month named by label.
+func periodBounds(period, label string) (time.Time, time.Time, error) {
+	switch period {
+	case domain.ReportPeriodWeekly:
+		var year, week int
+		if _, err := fmt.Sscanf(label, "%d-W%d", &year, &week); err != nil || week < 1 || week > 53 {
+			return time.Time{}, time.Time{}, domain.ErrInvalidReportPeriod
+		}
+		// January 4th always falls in ISO week 1.
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+		if y, w := monday.ISOWeek(); y != year || w != week {
+			return time.Time{}, time.Time{}, domain.ErrInvalidReportPeriod
+		}
+		return monday, monday.AddDate(0, 0, 6), nil
+	case domain.ReportPeriodMonthly:
+		first, err := time.Parse("2006-01", label)
+		if err != nil {
+			return time.Time{}, time.Time{}, domain.ErrInvalidReportPeriod
+		}
+		return first, first.AddDate(0, 1, -1), nil
+	}
+	return time.Time{}, time.Time{}, domain.ErrInvalidReportPeriod
+}
+
+func previousPeriod(period string, start time.Time) (time.Time, time.Time) {
+	if period == domain.ReportPeriodMonthly {
+		return start.AddDate(0, -1, 0), start.AddDate(0, 0, -1)
+	}
+	return start.AddDate(0, 0, -7), start.AddDate(0, 0, -1)
+}
+
+// renderDigest turns a report into a notification with push-length text and
+// an HTML email body, both in the user's locale.
+func renderDigest(user *domain.User, report *domain.ProgressReport) (*notifications.Notification, error) {
+	kind := domain.NotificationKindWeeklyDigest
+	if report.Period == domain.ReportPeriodMonthly {
+		kind = domain.NotificationKindMonthlyDigest
+	}
+	locale := user.Locale
+	title := i18n.T(locale, "digest."+report.Period+".title", nil)
+
+	summary := map[string]interface{}{
+		"DaysConsistent": report.DaysConsistent,
+		"TotalDays":      report.TotalDays,
+		"TotalProblems":  report.TotalProblems,
+		"Breakdown":      "",
+	}
+	if breakdown := platformBreakdown(report.ProblemsByPlatform); breakdown != "" {
+		summary["Breakdown"] = fmt.Sprintf(" (%s)", breakdown)
+	}
+	body := i18n.T(locale, "digest.summary", summary) + " " + i18n.T(locale, "digest.streak", report)
+	if report.Previous != nil {
+		delta := report.Previous.TotalProblemsDelta
+		comparison := "same"
+		switch {
+		case delta > 0:
+			comparison = "more"
+		case delta < 0:
+			comparison, delta = "fewer", -delta
+		}
+		body += " " + i18n.T(locale, "digest."+report.Period+"."+comparison, map[string]int{"Delta": delta})
+	}
+
+	tmpl, err := digestEmailTemplate.Clone()
+	if err != nil {
+		return nil, err
+	}
+	tmpl.Funcs(template.FuncMap{
+		"t": func(key string, data ...interface{}) string {
+			if len(data) == 0 {
+				return i18n.T(locale, key, nil)
+			}
+			return i18n.T(locale, key, data[0])
+		},
+	})
+	var html bytes.Buffer
+	err = tmpl.Execute(&html, struct {
+		Username  string
+		Title     string
+		Report    *domain.ProgressReport
+		Platforms []platformCount
+	}{user.Username, title, report, sortedPlatforms(report.ProblemsByPlatform)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &notifications.Notification{
+		Type:     kind,
+		Kind:     kind,
+		Title:    title,
+		Body:     body,
+		HTMLBody: html.String(),
+		Data:     map[string]string{"type": kind, "userId": user.ID.Hex(), "period": report.Label},
+	}, nil
+}
+
+type platformCount struct {
+	Platform string
+	Count    int
+}
+
+func sortedPlatforms(byPlatform map[string]int) []platformCount {
+	counts := make([]platformCount, 0, len(byPlatform))
+	for platform, count := range byPlatform {
+		counts = append(counts, platformCount{platform, count})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Platform < counts[j].Platform })
+	return counts
+}
+
+func platformBreakdown(byPlatform map[string]int) string {
+	parts := make([]string, 0, len(byPlatform))
+	for _, pc := range sortedPlatforms(byPlatform) {
+		parts = append(parts, fmt.Sprintf("%s %d", pc.Platform, pc.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// digestEmailTemplate is cloned per render with "t" bound to the user's locale.
+var digestEmailTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"t":    func(key string, data ...interface{}) string { return key },
+	"dict": digestDict,
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+  <h2>{{.Title}}</h2>
+  <p>{{t (printf "digest.email.%s.intro" .Report.Period) (dict "Username" .Username "Label" .Report.Label)}}</p>
+  <table cellpadding="6" style="border-collapse: collapse;">
+    <tr><td>{{t "digest.email.days_consistent"}}</td><td><strong>{{.Report.DaysConsistent}} / {{.Report.TotalDays}}</strong></td></tr>
+    <tr><td>{{t "digest.email.problems_solved"}}</td><td><strong>{{.Report.TotalProblems}}</strong></td></tr>
+    {{range .Platforms}}<tr><td>&nbsp;&nbsp;{{.Platform}}</td><td>{{.Count}}</td></tr>
+    {{end}}<tr><td>{{t "digest.email.current_streak"}}</td><td><strong>{{t "digest.email.days" (dict "Days" .Report.StreakAtEnd)}}</strong> ({{printf "%+d" .Report.StreakChange}})</td></tr>
+    <tr><td>{{t "digest.email.longest_streak"}}</td><td>{{t "digest.email.days" (dict "Days" .Report.LongestStreak)}}</td></tr>
+    {{with .Report.BestDay}}<tr><td>{{t "digest.email.best_day"}}</td><td>{{t "digest.email.best_day_value" (dict "Date" (.Date.Format "2006-01-02") "Solved" .ProblemsSolved)}}</td></tr>{{end}}
+    {{with .Report.Previous}}<tr><td>{{t "digest.email.compared_to" (dict "Label" .Label)}}</td><td>{{t "digest.email.comparison" (dict "Days" .DaysConsistentDelta "Problems" .TotalProblemsDelta)}}</td></tr>{{end}}
+  </table>
+  <p>{{t "digest.email.sign_off"}}</p>
+</body>
+</html>
+`))
+
+// digestDict builds the data map for a "t" call from alternating keys and values.
+func digestDict(pairs ...interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if key, ok := pairs[i].(string); ok {
+			m[key] = pairs[i+1]
+		}
+	}
+	return m
+}
@@ -3,10 +3,14 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"consistent_1/Domain"
 	"consistent_1/Infrastructure/auth"
+	"consistent_1/Infrastructure/i18n"
+	"consistent_1/Infrastructure/tracing"
 	"consistent_1/Repositories"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,25 +21,33 @@ type UserUsecase interface {
 	UpdateUserProfile(ctx context.Context, userID string, updates *domain.UserProfileUpdateRequest) error
 	GetUserProfile(ctx context.Context, userID string) (*domain.User, error)
 	GetAllUsers(ctx context.Context) ([]domain.User, error) 
+	RemoveDevice(ctx context.Context, userID string, token string) error
+	GetNotificationSettings(ctx context.Context, userID string) (*domain.NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, userID string, settings *domain.NotificationSettings) error
 }
 
 type userUsecase struct {
 	userRepo      repositories.UserRepository
 	passwordService auth.PasswordService
 	jwtService    auth.JWTService
+	platformUsecase PlatformUsecase
 }
 func NewUserUsecase(
 	userRepo repositories.UserRepository,
 	passwordService auth.PasswordService,
 	jwtService auth.JWTService,
+	platformUsecase PlatformUsecase,
 ) UserUsecase {
 	return &userUsecase{
 		userRepo:      userRepo,
 		passwordService: passwordService,
 		jwtService:    jwtService,
+		platformUsecase: platformUsecase,
 	}
 }
 func (uc *userUsecase) RegisterUser(ctx context.Context, req *domain.UserRegisterRequest) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUsecase.RegisterUser")
+	defer span.End()
 	if req.Password != req.ConfirmPassword {
 		return nil, domain.ErrPasswordsDoNotMatch
 	}
@@ -58,24 +70,40 @@ func (uc *userUsecase) RegisterUser(ctx context.Context, req *domain.UserRegiste
 	if err != nil {
 		return nil, fmt.Errorf("invalid timezone provided: %w", err)
 	}
+	locale := ""
+	if req.Locale != "" {
+		var ok bool
+		if locale, ok = i18n.Match(req.Locale); !ok {
+			return nil, domain.ErrUnsupportedLocale
+		}
+	}
 	user := &domain.User{
 		Email:              req.Email,
 		PasswordHash:       hashedPassword,
 		Username:           req.Username,
 		NotificationTime:   req.NotificationTime,
 		Timezone:           req.Timezone,
+		Locale:             locale,
 		PlatformUsernames:  make(map[string]string), 
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
-		FCMTokens:          []string{},              
+		Devices:            []domain.Device{},
+	}
+	if user.NextReminderAt, err = user.NextReminderAfter(time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to schedule first reminder: %w", err)
 	}
 	if err := uc.userRepo.CreateUser(ctx, user); err != nil {
+		if err == domain.ErrEmailAlreadyExists {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create user in database: %w", err)
 	}
 
 	return user, nil
 }
 func (uc *userUsecase) LoginUser(ctx context.Context, req *domain.UserLoginRequest) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserUsecase.LoginUser")
+	defer span.End()
 
 	user, err := uc.userRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -95,6 +123,8 @@ func (uc *userUsecase) LoginUser(ctx context.Context, req *domain.UserLoginReque
 	return token, nil
 }
 func (uc *userUsecase) UpdateUserProfile(ctx context.Context, userID string, updates *domain.UserProfileUpdateRequest) error {
+	ctx, span := tracing.Start(ctx, "UserUsecase.UpdateUserProfile")
+	defer span.End()
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return domain.ErrUserNotFound 
@@ -105,9 +135,7 @@ func (uc *userUsecase) UpdateUserProfile(ctx context.Context, userID string, upd
 		return err 
 	}
 
-	if updates.Username != nil {
-		user.Username = *updates.Username
-	}
+	update := &domain.UserUpdate{Username: updates.Username}
 	if updates.NotificationTime != nil {
 
 		_, err := time.Parse("15:04", *updates.NotificationTime)
@@ -115,6 +143,7 @@ func (uc *userUsecase) UpdateUserProfile(ctx context.Context, userID string, upd
 			return domain.ErrInvalidNotificationTime
 		}
 		user.NotificationTime = *updates.NotificationTime
+		update.NotificationTime = updates.NotificationTime
 	}
 	if updates.Timezone != nil {
 		_, err := time.LoadLocation(*updates.Timezone)
@@ -122,29 +151,171 @@ func (uc *userUsecase) UpdateUserProfile(ctx context.Context, userID string, upd
 			return fmt.Errorf("invalid timezone provided: %w", err)
 		}
 		user.Timezone = *updates.Timezone
+		update.Timezone = updates.Timezone
+
+		// Digests fire at local instants too. Clearing them lets the next
+		// dispatch tick reschedule them in the new zone.
+		var unscheduled time.Time
+		update.NextWeeklyDigestAt = &unscheduled
+		update.NextMonthlyDigestAt = &unscheduled
 	}
-	if updates.PlatformUsernames != nil {
-		user.PlatformUsernames = updates.PlatformUsernames
+	if updates.NotificationTime != nil || updates.Timezone != nil {
+		next, err := user.NextReminderAfter(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to reschedule reminder: %w", err)
+		}
+		update.NextReminderAt = &next
 	}
-	if updates.FCMToken != nil && *updates.FCMToken != "" {
-		found := false
-		for _, t := range user.FCMTokens {
-			if t == *updates.FCMToken {
-				found = true
-				break
+	if updates.NotificationChannels != nil {
+		for _, channel := range *updates.NotificationChannels {
+			if !domain.SupportedChannels[channel] {
+				return domain.ErrUnsupportedChannel
+			}
+		}
+		update.NotificationChannels = updates.NotificationChannels
+	}
+	if updates.TelegramChatID != nil {
+		chatID := strings.TrimSpace(*updates.TelegramChatID)
+		update.TelegramChatID = &chatID
+	}
+	if updates.WebhookURL != nil {
+		webhookURL := strings.TrimSpace(*updates.WebhookURL)
+		if webhookURL != "" {
+			parsed, err := url.Parse(webhookURL)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return domain.ErrInvalidWebhookURL
 			}
 		}
-		if !found {
-			user.FCMTokens = append(user.FCMTokens, *updates.FCMToken)
+		update.WebhookURL = &webhookURL
+	}
+	if updates.Locale != nil {
+		locale := ""
+		if *updates.Locale != "" {
+			matched, ok := i18n.Match(*updates.Locale)
+			if !ok {
+				return domain.ErrUnsupportedLocale
+			}
+			locale = matched
 		}
+		update.Locale = &locale
+	}
+	if updates.PlatformUsernames != nil {
+		if err := uc.validatePlatformUsernames(ctx, user.PlatformUsernames, updates.PlatformUsernames); err != nil {
+			return err
+		}
+		update.PlatformUsernames = updates.PlatformUsernames
 	}
-	user.ID = objID 
 
-	return uc.userRepo.UpdateUser(ctx, user)
+	if err := uc.userRepo.UpdateUser(ctx, objID, update); err != nil {
+		return err
+	}
+	if updates.FCMToken != nil && *updates.FCMToken != "" {
+		return uc.userRepo.RegisterDevice(ctx, objID, newDevice(*updates.FCMToken, updates.DevicePlatform, updates.AppVersion))
+	}
+	return nil
 }
 func (uc *userUsecase) GetUserProfile(ctx context.Context, userID string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUsecase.GetUserProfile")
+	defer span.End()
 	return uc.userRepo.GetUserByID(ctx, userID)
 }
 func (uc *userUsecase) GetAllUsers(ctx context.Context) ([]domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUsecase.GetAllUsers")
+	defer span.End()
 	return uc.userRepo.GetAllUsers(ctx)
-}
\ No newline at end of file
+}
+func (uc *userUsecase) RemoveDevice(ctx context.Context, userID string, token string) error {
+	ctx, span := tracing.Start(ctx, "UserUsecase.RemoveDevice")
+	defer span.End()
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	return uc.userRepo.RemoveDevice(ctx, objID, token)
+}
+
+// GetNotificationSettings returns the user's effective settings, listing every
+// notification kind so clients can render all toggles.
+func (uc *userUsecase) GetNotificationSettings(ctx context.Context, userID string) (*domain.NotificationSettings, error) {
+	ctx, span := tracing.Start(ctx, "UserUsecase.GetNotificationSettings")
+	defer span.End()
+	user, err := uc.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &domain.NotificationSettings{
+		Channels:   user.EnabledChannels(),
+		QuietHours: user.NotificationPreferences.QuietHours,
+		Types:      make(map[string]domain.KindPreference, len(domain.SupportedNotificationKinds)),
+	}
+	if settings.QuietHours == nil {
+		settings.QuietHours = []domain.QuietHoursWindow{}
+	}
+	for kind := range domain.SupportedNotificationKinds {
+		pref, ok := user.NotificationPreferences.Types[kind]
+		if !ok {
+			pref = domain.KindPreference{Enabled: true}
+		}
+		settings.Types[kind] = pref
+	}
+	return settings, nil
+}
+
+// UpdateNotificationSettings replaces the user's channels, quiet hours and
+// per-kind preferences with settings.
+func (uc *userUsecase) UpdateNotificationSettings(ctx context.Context, userID string, settings *domain.NotificationSettings) error {
+	ctx, span := tracing.Start(ctx, "UserUsecase.UpdateNotificationSettings")
+	defer span.End()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+
+	channels := settings.Channels
+	if len(channels) == 0 {
+		channels = []string{domain.ChannelPush}
+	}
+	return uc.userRepo.UpdateUser(ctx, objID, &domain.UserUpdate{
+		NotificationChannels: &channels,
+		NotificationPreferences: &domain.NotificationPreferences{
+			QuietHours: settings.QuietHours,
+			Types:      settings.Types,
+		},
+	})
+}
+
+// newDevice builds the device record for a token the client just presented.
+func newDevice(token string, platform, appVersion *string) domain.Device {
+	now := time.Now()
+	device := domain.Device{Token: token, LastSeen: now, CreatedAt: now}
+	if platform != nil {
+		device.Platform = *platform
+	}
+	if appVersion != nil {
+		device.AppVersion = *appVersion
+	}
+	return device
+}
+
+// validatePlatformUsernames checks every handle that is new or changed against
+// its platform. Empty handles unlink the platform and are not looked up.
+func (uc *userUsecase) validatePlatformUsernames(ctx context.Context, current, requested map[string]string) error {
+	for platform, username := range requested {
+		if !domain.SupportedPlatforms[platform] {
+			return &domain.PlatformHandleError{Platform: platform, Username: username, Err: domain.ErrUnsupportedPlatform}
+		}
+		username = strings.TrimSpace(username)
+		requested[platform] = username
+		if username == "" || current[platform] == username {
+			continue
+		}
+		if err := uc.platformUsecase.ValidatePlatformUsername(ctx, platform, username); err != nil {
+			return err
+		}
+	}
+	return nil
+}